@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/game"
+)
+
+// options are the settings of a load test
+type options struct {
+	server       string
+	tls          bool
+	mode         game.Mode
+	tickRate     int
+	screenWidth  int
+	screenHeight int
+	border       int
+	maxScore     int8
+}
+
+// url returns the address of the server path, with the secure variant of the scheme under TLS
+func (o options) url(scheme, path string) string {
+	if o.tls {
+		scheme += "s"
+	}
+
+	u := url.URL{Scheme: scheme, Host: o.server, Path: path}
+
+	return u.String()
+}
+
+// tickInterval returns the expected time between two game states
+func (o options) tickInterval() time.Duration {
+	return time.Second / time.Duration(o.tickRate)
+}
+
+// Load generator playing bot matches and watching them against a running server
+func main() {
+	server := flag.String("server", "localhost:8080", "The server address")
+	tls := flag.Bool("tls", false, "Connects to the server with TLS")
+	players := flag.Int("players", 10, "The number of concurrent bot players")
+	spectators := flag.Int("spectators", 0, "The number of concurrent spectators")
+	rampUp := flag.Duration("ramp-up", 10*time.Second, "The time over which the connections are opened")
+	duration := flag.Duration("duration", time.Minute, "The length of the load test, including the ramp up")
+	mode := flag.String("mode", string(game.ModeClassic), "The game mode the bots queue for")
+	tickRate := flag.Int("tick-rate", 60, "The tick rate of the server, the expected game states per second")
+	maxScore := flag.Int("max-score", 5, "The max score of the bots matches")
+	seed := flag.Uint64("seed", 0, "The seed of the bots behavior, random by default")
+	jsonReport := flag.Bool("json", false, "Prints the report as JSON")
+	flag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	o := options{
+		server:       *server,
+		tls:          *tls,
+		mode:         game.Mode(*mode),
+		tickRate:     *tickRate,
+		screenWidth:  640,
+		screenHeight: 480,
+		border:       10,
+		maxScore:     int8(*maxScore),
+	}
+
+	if err := o.mode.Validate(); err != nil || *players < 0 || *spectators < 0 || *tickRate < 1 || *maxScore < 1 || *maxScore > 127 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Load testing %s with %d players and %d spectators for %s, seed %d\n", o.server, *players, *spectators, *duration, *seed)
+
+	m := newMetrics()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	launch := func(count int, run func(i int)) {
+		for i := range count {
+			delay := time.Duration(0)
+			if count > 1 {
+				delay = *rampUp * time.Duration(i) / time.Duration(count-1)
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(delay):
+				}
+
+				run(i)
+			}()
+		}
+	}
+
+	launch(*players, func(i int) {
+		b := &bot{
+			name:    fmt.Sprintf("bot-%d", i+1),
+			options: o,
+			metrics: m,
+			rand:    rand.New(rand.NewPCG(*seed, uint64(i))),
+		}
+		b.run(ctx)
+	})
+
+	launch(*spectators, func(i int) {
+		w := &watcher{
+			options: o,
+			metrics: m,
+			rand:    rand.New(rand.NewPCG(*seed, uint64(*players+i))),
+		}
+		w.run(ctx)
+	})
+
+	wg.Wait()
+
+	report := m.report(time.Since(start), *players, *spectators)
+	if *jsonReport {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+
+	report.print(os.Stdout)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// samples records the durations of a measure, safe for concurrent use
+type samples struct {
+	mutex  sync.Mutex
+	values []time.Duration
+}
+
+func (s *samples) add(value time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values = append(s.values, value)
+}
+
+// Distribution sums up the samples of a measure
+type Distribution struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+func (s *samples) distribution() Distribution {
+	s.mutex.Lock()
+	values := slices.Clone(s.values)
+	s.mutex.Unlock()
+
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	slices.Sort(values)
+
+	var total time.Duration
+	for _, value := range values {
+		total += value
+	}
+
+	percentile := func(p int) time.Duration {
+		return values[(len(values)-1)*p/100]
+	}
+
+	return Distribution{
+		Count: len(values),
+		Min:   values[0],
+		Mean:  total / time.Duration(len(values)),
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P99:   percentile(99),
+		Max:   values[len(values)-1],
+	}
+}
+
+// counters counts events by name, safe for concurrent use
+type counters struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func newCounters() *counters {
+	return &counters{counts: make(map[string]int)}
+}
+
+func (c *counters) add(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.counts[name]++
+}
+
+func (c *counters) snapshot() map[string]int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return maps.Clone(c.counts)
+}
+
+// metrics are the measures of a load test
+//
+// The state interval is the time between two game states received by a client, and the
+// jitter its difference with the tick interval. The input latency is the time from an
+// input sent by a bot to the first game state showing its paddle moved, and the match
+// found time the time from joining the queue to the ready message.
+type metrics struct {
+	stateInterval     samples
+	stateJitter       samples
+	spectatorInterval samples
+	spectatorJitter   samples
+	inputLatency      samples
+	matchFound        samples
+	events            *counters
+	errors            *counters
+}
+
+func newMetrics() *metrics {
+	return &metrics{events: newCounters(), errors: newCounters()}
+}
+
+// interval records the time between two game states of a player or a spectator
+func (m *metrics) interval(spectator bool, interval, tickInterval time.Duration) {
+	jitter := interval - tickInterval
+	if jitter < 0 {
+		jitter = -jitter
+	}
+
+	if spectator {
+		m.spectatorInterval.add(interval)
+		m.spectatorJitter.add(jitter)
+		return
+	}
+
+	m.stateInterval.add(interval)
+	m.stateJitter.add(jitter)
+}
+
+// Report is the summary of a load test
+type Report struct {
+	Duration          time.Duration  `json:"duration"`
+	Players           int            `json:"players"`
+	Spectators        int            `json:"spectators"`
+	StateInterval     Distribution   `json:"state_interval"`
+	StateJitter       Distribution   `json:"state_jitter"`
+	SpectatorInterval Distribution   `json:"spectator_interval"`
+	SpectatorJitter   Distribution   `json:"spectator_jitter"`
+	InputLatency      Distribution   `json:"input_latency"`
+	MatchFound        Distribution   `json:"match_found"`
+	Events            map[string]int `json:"events"`
+	Errors            map[string]int `json:"errors"`
+}
+
+func (m *metrics) report(duration time.Duration, players, spectators int) Report {
+	return Report{
+		Duration:          duration,
+		Players:           players,
+		Spectators:        spectators,
+		StateInterval:     m.stateInterval.distribution(),
+		StateJitter:       m.stateJitter.distribution(),
+		SpectatorInterval: m.spectatorInterval.distribution(),
+		SpectatorJitter:   m.spectatorJitter.distribution(),
+		InputLatency:      m.inputLatency.distribution(),
+		MatchFound:        m.matchFound.distribution(),
+		Events:            m.events.snapshot(),
+		Errors:            m.errors.snapshot(),
+	}
+}
+
+// print writes the report as a human readable summary
+func (r Report) print(w io.Writer) {
+	fmt.Fprintf(w, "Load test of %s with %d players and %d spectators\n\n", r.Duration.Round(time.Second), r.Players, r.Spectators)
+
+	fmt.Fprintf(w, "%-20s %8s %10s %10s %10s %10s %10s %10s\n", "", "count", "min", "mean", "p50", "p90", "p99", "max")
+	distributions := []struct {
+		name         string
+		distribution Distribution
+	}{
+		{"state interval", r.StateInterval},
+		{"state jitter", r.StateJitter},
+		{"spectator interval", r.SpectatorInterval},
+		{"spectator jitter", r.SpectatorJitter},
+		{"input latency", r.InputLatency},
+		{"match found", r.MatchFound},
+	}
+
+	for _, d := range distributions {
+		fmt.Fprintf(w, "%-20s %8d %10s %10s %10s %10s %10s %10s\n", d.name, d.distribution.Count,
+			round(d.distribution.Min), round(d.distribution.Mean), round(d.distribution.P50),
+			round(d.distribution.P90), round(d.distribution.P99), round(d.distribution.Max))
+	}
+
+	for _, counts := range []struct {
+		name   string
+		counts map[string]int
+	}{{"Events", r.Events}, {"Errors", r.Errors}} {
+		fmt.Fprintf(w, "\n%s:\n", counts.name)
+		if len(counts.counts) == 0 {
+			fmt.Fprintln(w, "  none")
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(counts.counts)) {
+			fmt.Fprintf(w, "  %-30s %d\n", name, counts.counts[name])
+		}
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
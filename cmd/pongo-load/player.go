@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/gorilla/websocket"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// paddleStep is the distance a paddle moves on each input, assuming the server runs with
+// the default paddle speed
+var paddleStep = config.Default().Game.PaddleSpeed
+
+const (
+	// latencyTimeout is the time after which an input that didn't move the paddle is given up
+	latencyTimeout = 2 * time.Second
+	// retryDelay is the time a client waits before connecting again after a failure
+	retryDelay = time.Second
+)
+
+// serverMessage is any message sent by the server to a player or a spectator
+type serverMessage struct {
+	Ready    bool          `json:"ready"`
+	Snapshot bool          `json:"snapshot"`
+	Side     geometry.Side `json:"side"`
+	game.GameState
+}
+
+// bot is a player queuing for matches one after the other until the load test ends
+//
+// It follows the ball like a person would: it only looks where the ball goes every
+// reaction time, between 150 and 300 milliseconds, and aims a bit off its center.
+type bot struct {
+	name    string
+	options options
+	metrics *metrics
+	rand    *rand.Rand
+
+	side      geometry.Side
+	lastState time.Time
+	nextLook  time.Time
+	target    float64
+	pending   *pendingInput
+}
+
+// pendingInput is an input waiting for the paddle to move, to measure its latency
+type pendingInput struct {
+	sent     time.Time
+	position float64
+	up       bool
+}
+
+// run plays matches until the context is done
+func (b *bot) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := b.play(ctx); err != nil && ctx.Err() == nil {
+			b.metrics.errors.add(err.Error())
+			slog.Warn("Bot failed", slog.String("name", b.name), slog.Any("error", err))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+		}
+	}
+}
+
+// play queues for a match and plays it until the server closes the connection
+func (b *bot) play(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, b.options.url("ws", "/multiplayer"), nil)
+	if err != nil {
+		return errors.New("dial failed")
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go closeOnDone(ctx, done, conn)
+
+	b.lastState, b.pending = time.Time{}, nil
+
+	joined := time.Now()
+	info := game.GameInfo{
+		PlayerName:       b.name,
+		Mode:             b.options.mode,
+		ScreenWidth:      b.options.screenWidth,
+		ScreenHeight:     b.options.screenHeight,
+		FieldBorderWidth: b.options.border,
+		MaxScore:         b.options.maxScore,
+	}
+	if err := conn.WriteJSON(info); err != nil {
+		return errors.New("write failed")
+	}
+
+	b.metrics.events.add("players queued")
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return b.closed(ctx, err)
+		}
+
+		var message serverMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return errors.New("invalid message")
+		}
+
+		switch {
+		case message.Ready:
+			b.side = message.Side
+			b.metrics.matchFound.add(time.Since(joined))
+			b.metrics.events.add("matches found")
+		case message.Tick > 0:
+			if err := b.update(conn, message.GameState); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// closed classifies the end of the connection, the end of a match not being an error
+func (b *bot) closed(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return errors.New("connection lost")
+	}
+
+	switch closeErr.Text {
+	case "You won!", "You lost!":
+		b.metrics.events.add("matches played")
+		return nil
+	case "Opponent disconnected", "Teammate disconnected":
+		b.metrics.events.add("matches abandoned")
+		return nil
+	}
+
+	return errors.New("closed: " + closeErr.Text)
+}
+
+// update measures the game state and answers it with the bot's input
+func (b *bot) update(conn *websocket.Conn, state game.GameState) error {
+	now := time.Now()
+	if !b.lastState.IsZero() {
+		b.metrics.interval(false, now.Sub(b.lastState), b.options.tickInterval())
+	}
+	b.lastState = now
+
+	position := state.Current.PositionY
+
+	if b.pending != nil {
+		moved := position > b.pending.position
+		if b.pending.up {
+			moved = position < b.pending.position
+		}
+
+		switch {
+		case moved:
+			b.metrics.inputLatency.add(now.Sub(b.pending.sent))
+			b.pending = nil
+		case now.Sub(b.pending.sent) > latencyTimeout:
+			b.metrics.events.add("inputs unacknowledged")
+			b.pending = nil
+		}
+	}
+
+	input := b.decide(state, now)
+	if !input.Up && !input.Down && !input.Serve {
+		return nil
+	}
+
+	if err := conn.WriteJSON(input); err != nil {
+		return errors.New("write failed")
+	}
+
+	if b.pending == nil && (input.Up || input.Down) {
+		b.pending = &pendingInput{sent: now, position: position, up: input.Up}
+	}
+
+	return nil
+}
+
+// decide steers the paddle toward where the bot last saw the ball, serving the balls
+// waiting for it
+func (b *bot) decide(state game.GameState, now time.Time) game.PlayerInput {
+	length := 0.0
+	for _, paddle := range state.Paddles {
+		if paddle.Name == b.name {
+			length = paddle.Length
+		}
+	}
+
+	if !now.Before(b.nextLook) {
+		b.nextLook = now.Add(150*time.Millisecond + time.Duration(b.rand.Int64N(int64(150*time.Millisecond))))
+		b.target = state.Ball.Position.Y + physics.BallWidth/2 + (b.rand.Float64()-0.5)*length/2
+	}
+
+	middle := state.Current.PositionY + length/2
+	serve := state.Ball.Serve
+	button := serve != nil && serve.Button && serve.Toward == b.side
+
+	return game.PlayerInput{
+		Up:    b.target < middle-paddleStep,
+		Down:  b.target > middle+paddleStep,
+		Serve: button && b.rand.IntN(10) == 0,
+	}
+}
+
+// closeOnDone closes the connection once the context is done, unless the client stopped first
+func closeOnDone(ctx context.Context, done <-chan struct{}, conn *websocket.Conn) {
+	select {
+	case <-ctx.Done():
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Load test ended"), time.Now().Add(time.Second))
+	case <-done:
+	}
+
+	conn.Close()
+}
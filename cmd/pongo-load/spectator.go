@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/reneepc/pongo-server/internal/game"
+)
+
+// watcher is a spectator watching random sessions one after the other until the load test ends
+type watcher struct {
+	options options
+	metrics *metrics
+	rand    *rand.Rand
+}
+
+// run watches sessions until the context is done, waiting for sessions to start when there are none
+func (w *watcher) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := w.watch(ctx)
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+
+		if !errors.Is(err, errNoSessions) {
+			w.metrics.errors.add(err.Error())
+			slog.Warn("Spectator failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+var errNoSessions = errors.New("no sessions to watch")
+
+// watch spectates a random session until it ends
+func (w *watcher) watch(ctx context.Context) error {
+	id, err := w.pickSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, w.options.url("ws", "/spectate"), nil)
+	if err != nil {
+		return errors.New("spectator dial failed")
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go closeOnDone(ctx, done, conn)
+
+	if err := conn.WriteJSON(map[string]string{"session_id": id}); err != nil {
+		return errors.New("spectator write failed")
+	}
+
+	var lastState time.Time
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return w.closed(ctx, err)
+		}
+
+		var message serverMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return errors.New("invalid spectator message")
+		}
+
+		switch {
+		case message.Snapshot:
+			w.metrics.events.add("sessions watched")
+		case message.Tick > 0:
+			now := time.Now()
+			if !lastState.IsZero() {
+				w.metrics.interval(true, now.Sub(lastState), w.options.tickInterval())
+			}
+			lastState = now
+		}
+	}
+}
+
+// pickSession returns the ID of a random session listed by the server
+func (w *watcher) pickSession(ctx context.Context) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, w.options.url("http", "/sessions"), nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", errors.New("sessions request failed")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New("sessions request failed: " + response.Status)
+	}
+
+	var sessions []game.SessionSummary
+	if err := json.NewDecoder(response.Body).Decode(&sessions); err != nil {
+		return "", errors.New("invalid sessions response")
+	}
+
+	if len(sessions) == 0 {
+		return "", errNoSessions
+	}
+
+	return sessions[w.rand.IntN(len(sessions))].ID, nil
+}
+
+// closed classifies the end of the connection, the end of the watched session not being an error
+//
+// The server drops the spectators connections without a close message once the stream of
+// their session ends.
+func (w *watcher) closed(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return errors.New("spectator connection lost")
+	}
+
+	if closeErr.Code == websocket.CloseAbnormalClosure {
+		w.metrics.events.add("sessions ended")
+		return nil
+	}
+
+	return errors.New("spectator closed: " + closeErr.Text)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// paddleStep is the distance a paddle moves on each input, the simulated sessions using
+// the default paddle speed
+var paddleStep = config.Default().Game.PaddleSpeed
+
+const (
+	// sloppyReaction is the number of ticks between the decisions of the sloppy bot
+	sloppyReaction = 10
+	// randomHold is the number of ticks the random bot keeps its direction
+	randomHold = 15
+)
+
+// bot decides the input of a player from the state of the game
+type bot interface {
+	input(state game.GameState, paddle game.PaddleState) game.PlayerInput
+}
+
+// field is the playing area seen by the bots
+type field struct {
+	width  float64
+	height float64
+	border float64
+}
+
+// strategies are the bot strategies by name
+var strategies = map[string]func(f field, random *rand.Rand) bot{
+	"idle":    func(f field, random *rand.Rand) bot { return idleBot{} },
+	"random":  func(f field, random *rand.Rand) bot { return &randomBot{rand: random} },
+	"follow":  func(f field, random *rand.Rand) bot { return followBot{field: f} },
+	"predict": func(f field, random *rand.Rand) bot { return predictBot{field: f} },
+	"sloppy":  func(f field, random *rand.Rand) bot { return &sloppyBot{field: f, rand: random} },
+}
+
+// idleBot never moves, only serving the balls served toward it
+type idleBot struct{}
+
+func (idleBot) input(state game.GameState, paddle game.PaddleState) game.PlayerInput {
+	return game.PlayerInput{Serve: serving(state, paddle.Side)}
+}
+
+// randomBot moves in a random direction, changing it every few ticks
+type randomBot struct {
+	rand      *rand.Rand
+	direction int
+}
+
+func (b *randomBot) input(state game.GameState, paddle game.PaddleState) game.PlayerInput {
+	if state.Tick%randomHold == 0 {
+		b.direction = b.rand.IntN(3) - 1
+	}
+
+	return game.PlayerInput{
+		Up:    b.direction < 0,
+		Down:  b.direction > 0,
+		Serve: serving(state, paddle.Side),
+	}
+}
+
+// followBot keeps its paddle in front of the next ball coming toward its wall
+type followBot struct {
+	field field
+}
+
+func (b followBot) input(state game.GameState, paddle game.PaddleState) game.PlayerInput {
+	target := b.field.middle(paddle.Side)
+	if ball, ok := incoming(state, paddle); ok {
+		target = along(center(ball), paddle.Side)
+	}
+
+	return move(paddle, target, serving(state, paddle.Side))
+}
+
+// predictBot moves its paddle to where the next ball coming toward its wall will reach it,
+// following its bounces on the side walls
+type predictBot struct {
+	field field
+}
+
+func (b predictBot) input(state game.GameState, paddle game.PaddleState) game.PlayerInput {
+	target := b.field.middle(paddle.Side)
+	if ball, ok := incoming(state, paddle); ok {
+		target = b.field.intercept(ball, paddle)
+	}
+
+	return move(paddle, target, serving(state, paddle.Side))
+}
+
+// sloppyBot follows the balls, but only decides where to go every few ticks and misses
+// its target by up to half of its paddle
+type sloppyBot struct {
+	field  field
+	rand   *rand.Rand
+	target float64
+}
+
+func (b *sloppyBot) input(state game.GameState, paddle game.PaddleState) game.PlayerInput {
+	if state.Tick%sloppyReaction == 0 {
+		b.target = b.field.middle(paddle.Side)
+		if ball, ok := incoming(state, paddle); ok {
+			b.target = along(center(ball), paddle.Side) + (b.rand.Float64()-0.5)*paddle.Length
+		}
+	}
+
+	return move(paddle, b.target, serving(state, paddle.Side))
+}
+
+// move steers the paddle's center toward the target, along its wall
+func move(paddle game.PaddleState, target float64, serve bool) game.PlayerInput {
+	middle := along(paddle.Position, paddle.Side) + paddle.Length/2
+
+	return game.PlayerInput{
+		Up:    target < middle-paddleStep,
+		Down:  target > middle+paddleStep,
+		Serve: serve,
+	}
+}
+
+// serving reports whether a ball waits to be served toward the side
+func serving(state game.GameState, side geometry.Side) bool {
+	for _, ball := range state.Balls {
+		if ball.Serve != nil && ball.Serve.Toward == side {
+			return true
+		}
+	}
+
+	return false
+}
+
+// incoming returns the ball in play reaching the paddle's wall first
+func incoming(state game.GameState, paddle game.PaddleState) (game.BallState, bool) {
+	var next game.BallState
+	found, soonest := false, math.Inf(1)
+
+	for _, ball := range state.Balls {
+		if ball.Serve != nil {
+			continue
+		}
+
+		dx, dy := velocity(ball)
+		distance, speed := across(paddle.Position, paddle.Side)-across(center(ball), paddle.Side), across(geometry.Vector{X: dx, Y: dy}, paddle.Side)
+		if distance*speed <= 0 {
+			continue
+		}
+
+		if time := distance / speed; time < soonest {
+			next, found, soonest = ball, true, time
+		}
+	}
+
+	return next, found
+}
+
+// intercept returns the position along the paddle's wall where the ball will reach it,
+// bouncing on the walls on both ends of the paddle's wall
+func (f field) intercept(ball game.BallState, paddle game.PaddleState) float64 {
+	dx, dy := velocity(ball)
+	position := center(ball)
+	direction := geometry.Vector{X: dx, Y: dy}
+
+	time := (across(paddle.Position, paddle.Side) - across(position, paddle.Side)) / across(direction, paddle.Side)
+	reached := along(position, paddle.Side) + along(direction, paddle.Side)*time
+
+	length := f.height
+	if horizontal(paddle.Side) {
+		length = f.width
+	}
+
+	return reflect(reached, f.border+physics.BallWidth/2, length-f.border-physics.BallWidth/2)
+}
+
+// middle returns the middle of the side's wall
+func (f field) middle(side geometry.Side) float64 {
+	if horizontal(side) {
+		return f.width / 2
+	}
+
+	return f.height / 2
+}
+
+// reflect folds the position into the range, as a ball bouncing on both of its ends
+func reflect(position, low, high float64) float64 {
+	span := high - low
+	if span <= 0 {
+		return low
+	}
+
+	offset := math.Mod(position-low, 2*span)
+	if offset < 0 {
+		offset += 2 * span
+	}
+
+	if offset > span {
+		offset = 2*span - offset
+	}
+
+	return low + offset
+}
+
+func velocity(ball game.BallState) (float64, float64) {
+	angle := ball.Angle * math.Pi / 180
+
+	return math.Cos(angle), math.Sin(angle)
+}
+
+func center(ball game.BallState) geometry.Vector {
+	return geometry.Vector{X: ball.Position.X + physics.BallWidth/2, Y: ball.Position.Y + physics.BallWidth/2}
+}
+
+// horizontal reports whether the paddles of the side move horizontally, along the top and bottom walls
+func horizontal(side geometry.Side) bool {
+	return side == physics.Top || side == physics.Bottom
+}
+
+// along returns the coordinate of the vector along the side's wall
+func along(v geometry.Vector, side geometry.Side) float64 {
+	if horizontal(side) {
+		return v.X
+	}
+
+	return v.Y
+}
+
+// across returns the coordinate of the vector across the side's wall
+func across(v geometry.Vector, side geometry.Side) float64 {
+	if horizontal(side) {
+		return v.Y
+	}
+
+	return v.X
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/engine/level"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// Definition describes a batch of simulated matches
+//
+// Every match of the batch is played from its own seed, the Seed of the batch plus the
+// index of the match, so any match can be reproduced on its own. The Bots are the
+// strategies of the players of each side, in the order of the mode's sides. Matches still
+// going on after MaxTicks end without a winner.
+type Definition struct {
+	Name           string    `json:"name"`
+	Seed           uint64    `json:"seed"`
+	Matches        int       `json:"matches"`
+	Mode           game.Mode `json:"mode"`
+	Level          string    `json:"level"`
+	Layout         string    `json:"layout,omitempty"`
+	PowerUps       bool      `json:"power_ups,omitempty"`
+	ServeRule      string    `json:"serve_rule,omitempty"`
+	MultiballBalls int       `json:"multiball_balls,omitempty"`
+	ArenaLives     int       `json:"arena_lives,omitempty"`
+	ScreenWidth    int       `json:"screen_width"`
+	ScreenHeight   int       `json:"screen_height"`
+	Border         int       `json:"border"`
+	MaxScore       int8      `json:"max_score"`
+	MaxTicks       uint64    `json:"max_ticks"`
+	Bots           []string  `json:"bots"`
+}
+
+// SeedFile is a file of match definitions
+type SeedFile struct {
+	Definitions []Definition `json:"definitions"`
+}
+
+// defaultDefinition holds the settings of the definitions that leave them out
+var defaultDefinition = Definition{
+	Matches:      100,
+	Mode:         game.ModeClassic,
+	Level:        "medium",
+	ScreenWidth:  640,
+	ScreenHeight: 480,
+	Border:       10,
+	MaxScore:     5,
+	MaxTicks:     60 * 60 * 10,
+}
+
+var levels = map[string]level.Level{
+	"easy":   level.Easy,
+	"medium": level.Medium,
+	"hard":   level.Hard,
+}
+
+// loadSeedFile reads the definitions of the seed file, filling in the default settings
+func loadSeedFile(path string, layouts physics.Layouts) ([]Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Definitions []json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	definitions := make([]Definition, 0, len(raw.Definitions))
+	for i, message := range raw.Definitions {
+		definition := defaultDefinition
+		if err := json.Unmarshal(message, &definition); err != nil {
+			return nil, fmt.Errorf("%s: definitions[%d]: %w", path, i, err)
+		}
+
+		if definition.Mode == "" {
+			definition.Mode = game.ModeClassic
+		}
+
+		if definition.Name == "" {
+			definition.Name = fmt.Sprintf("%s-%s", definition.Mode, strings.Join(definition.Bots, "-"))
+		}
+
+		if err := definition.validate(layouts); err != nil {
+			return nil, fmt.Errorf("%s: definitions[%d]: %w", path, i, err)
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, nil
+}
+
+// validate returns every invalid setting of the definition
+func (d Definition) validate(layouts physics.Layouts) error {
+	var errs []error
+
+	if err := d.Mode.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("mode %q: %w", d.Mode, err))
+	}
+
+	if _, ok := levels[d.Level]; !ok {
+		errs = append(errs, fmt.Errorf("level must be easy, medium or hard, got %q", d.Level))
+	}
+
+	if _, err := layouts.Get(d.Layout); err != nil {
+		errs = append(errs, fmt.Errorf("layout %q: %w", d.Layout, err))
+	}
+
+	if d.Matches <= 0 {
+		errs = append(errs, fmt.Errorf("matches must be positive, got %d", d.Matches))
+	}
+
+	if d.MaxScore <= 0 {
+		errs = append(errs, fmt.Errorf("max_score must be positive, got %d", d.MaxScore))
+	}
+
+	if d.MaxTicks == 0 {
+		errs = append(errs, errors.New("max_ticks must be positive"))
+	}
+
+	if len(d.Bots) != len(d.Mode.Sides()) {
+		errs = append(errs, fmt.Errorf("bots must name a strategy for each of the %d sides, got %d", len(d.Mode.Sides()), len(d.Bots)))
+	}
+
+	for _, name := range d.Bots {
+		if _, ok := strategies[name]; !ok {
+			errs = append(errs, fmt.Errorf("unknown bot strategy %q", name))
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Game = d.gameConfig()
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// gameConfig returns the game configuration of the definition's sessions
+func (d Definition) gameConfig() config.Game {
+	cfg := config.Default().Game
+	if !d.PowerUps {
+		cfg.PowerUpInterval = 0
+	}
+
+	if d.ServeRule != "" {
+		cfg.ServeRule = d.ServeRule
+	}
+
+	if d.MultiballBalls > 0 {
+		cfg.MultiballBalls = d.MultiballBalls
+	}
+
+	if d.ArenaLives > 0 {
+		cfg.ArenaLives = d.ArenaLives
+	}
+
+	return cfg
+}
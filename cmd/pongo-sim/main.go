@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// Headless simulator playing bot matches on the server game sessions, as fast as the CPU allows
+func main() {
+	seedsFile := flag.String("seeds", "", "The JSON file of the match definitions to simulate")
+	layoutsDir := flag.String("layouts", "", "The directory of the field layouts used by the definitions")
+	workers := flag.Int("workers", runtime.NumCPU(), "The number of matches simulated at once")
+	format := flag.String("format", "json", "The report format: json or csv")
+	output := flag.String("out", "", "The file the report is written to, the standard output by default")
+	flag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if *seedsFile == "" || (*format != "json" && *format != "csv") || *workers < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	layouts, err := physics.LoadLayouts(*layoutsDir)
+	if err != nil {
+		slog.Error("Failed to load the field layouts", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	definitions, err := loadSeedFile(*seedsFile, layouts)
+	if err != nil {
+		slog.Error("Failed to load the match definitions", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	report := run(definitions, layouts, *workers)
+
+	matches := 0
+	for _, definition := range report.Definitions {
+		matches += definition.Matches
+	}
+	fmt.Fprintf(os.Stderr, "Simulated %d matches in %s\n", matches, time.Since(start).Round(time.Millisecond))
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			slog.Error("Failed to create the report file", slog.Any("error", err))
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	if *format == "csv" {
+		err = report.writeCSV(w)
+	} else {
+		err = report.writeJSON(w)
+	}
+
+	if err != nil {
+		slog.Error("Failed to write the report", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+// match is a match to simulate, the index of its definition along with its seed
+type match struct {
+	definition int
+	seed       uint64
+}
+
+// run simulates every match of the definitions on the given number of workers
+func run(definitions []Definition, layouts physics.Layouts, workers int) Report {
+	matches := make(chan match)
+	outcomes := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for m := range matches {
+				outcomes <- simulate(m.definition, definitions[m.definition], m.seed, layouts)
+			}
+		}()
+	}
+
+	go func() {
+		for i, definition := range definitions {
+			for n := range definition.Matches {
+				matches <- match{definition: i, seed: definition.Seed + uint64(n)}
+			}
+		}
+		close(matches)
+
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	tallies := make([]*tally, 0, len(definitions))
+	for _, definition := range definitions {
+		tallies = append(tallies, newTally(definition))
+	}
+
+	for result := range outcomes {
+		tallies[result.definition].add(result)
+	}
+
+	report := Report{Definitions: make([]DefinitionReport, 0, len(tallies))}
+	for _, t := range tallies {
+		report.Definitions = append(report.Definitions, t.report())
+	}
+
+	return report
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// outcome is what happened in a simulated match
+//
+// The goal times are the ticks each ball took to score, since it was put in play or
+// since its previous goal, and the hit speeds are the ball speeds after each paddle hit.
+type outcome struct {
+	definition int
+	seed       uint64
+	winner     geometry.Side
+	ended      bool
+	ticks      uint64
+	rallies    []int
+	goalTimes  []uint64
+	hitSpeeds  []float64
+	stats      game.MatchStats
+}
+
+// simulate plays a match of the definition from the seed, stepping the session as fast as possible
+func simulate(index int, definition Definition, seed uint64, layouts physics.Layouts) outcome {
+	cfg := definition.gameConfig()
+	mode := definition.Mode
+	layout, _ := layouts.Get(definition.Layout)
+
+	sides := mode.Sides()
+	size := mode.TeamSize()
+
+	f := field{
+		width:  float64(definition.ScreenWidth),
+		height: float64(definition.ScreenHeight),
+		border: float64(definition.Border),
+	}
+	if mode == game.ModeArena {
+		f.width = min(f.width, f.height)
+		f.height = f.width
+	}
+
+	players := make([]*game.Player, 0, len(sides)*size)
+	bots := make([]bot, 0, len(sides)*size)
+	for i := range len(sides) * size {
+		strategy := definition.Bots[i/size]
+
+		network := game.NewNetwork(nil, game.GameInfo{
+			PlayerName:       fmt.Sprintf("%s-%d", strategy, i+1),
+			Mode:             mode,
+			PowerUps:         definition.PowerUps,
+			Layout:           definition.Layout,
+			ScreenWidth:      definition.ScreenWidth,
+			ScreenHeight:     definition.ScreenHeight,
+			FieldBorderWidth: definition.Border,
+			MaxScore:         definition.MaxScore,
+		})
+
+		players = append(players, game.NewPlayer(network, sides[i/size], cfg))
+		bots = append(bots, strategies[strategy](f, rand.New(rand.NewPCG(seed, uint64(i)))))
+	}
+
+	session := game.NewGameSession(mode, layout, players, cfg, game.NewSessionManager(0), nil, nil,
+		game.WithSeed(seed),
+		game.WithClock(game.NewManualClock(time.Unix(0, 0))),
+		game.WithLevel(levels[definition.Level]),
+	)
+
+	result := outcome{definition: index, seed: seed}
+	inPlay := make(map[int]uint64)
+	inputs := make([][]game.PlayerInput, len(players))
+
+	for result.ticks < definition.MaxTicks {
+		state := session.Step(inputs)
+		result.ticks = state.Tick
+
+		for _, ball := range state.Balls {
+			if _, ok := inPlay[ball.ID]; !ok {
+				inPlay[ball.ID] = state.Tick
+			}
+		}
+
+		for _, event := range state.Events {
+			switch event.Type {
+			case game.PlayHit:
+				result.hitSpeeds = append(result.hitSpeeds, event.Speed)
+			case game.PlayGoal:
+				result.rallies = append(result.rallies, event.Rally)
+				result.goalTimes = append(result.goalTimes, state.Tick-inPlay[event.Ball])
+				inPlay[event.Ball] = state.Tick
+			}
+		}
+
+		if session.Ended() {
+			result.ended = true
+			break
+		}
+
+		for i, b := range bots {
+			inputs[i] = []game.PlayerInput{b.input(state, state.Paddles[i])}
+		}
+	}
+
+	matchResult := session.Result()
+	result.winner = matchResult.WinnerSide
+	result.stats = matchResult.Stats
+
+	return result
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+const (
+	rallyBucketWidth = 1
+	speedBucketWidth = 0.5
+)
+
+// Report holds the results of every definition of a simulation
+type Report struct {
+	Definitions []DefinitionReport `json:"definitions"`
+}
+
+// DefinitionReport sums up the matches of a definition
+//
+// The unfinished matches reached the definition's max ticks without a winner. The rally
+// lengths count the paddle hits between each serve and goal, the goal time is the time a
+// ball took to score since it was served, and the ball speeds are the speeds of the balls
+// after each paddle hit, in pixels per tick.
+type DefinitionReport struct {
+	Name            string       `json:"name"`
+	Mode            string       `json:"mode"`
+	Level           string       `json:"level"`
+	Layout          string       `json:"layout,omitempty"`
+	Seed            uint64       `json:"seed"`
+	Matches         int          `json:"matches"`
+	Unfinished      int          `json:"unfinished"`
+	Sides           []SideReport `json:"sides"`
+	Goals           int          `json:"goals"`
+	AverageRally    float64      `json:"average_rally"`
+	LongestRally    int          `json:"longest_rally"`
+	AverageGoalTime float64      `json:"average_goal_time_ms"`
+	AverageDuration float64      `json:"average_duration_ms"`
+	TopSpeed        float64      `json:"top_speed"`
+	RallyLengths    []Bucket     `json:"rally_lengths"`
+	BallSpeeds      []Bucket     `json:"ball_speeds"`
+}
+
+// SideReport is the share of the matches won by the bots of a side
+type SideReport struct {
+	Side    string  `json:"side"`
+	Bot     string  `json:"bot"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"`
+}
+
+// Bucket counts the values of a histogram from its lower bound, included, to its upper bound
+type Bucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// histogram counts values in buckets of a fixed width
+type histogram struct {
+	width  float64
+	counts map[int]int
+}
+
+func newHistogram(width float64) histogram {
+	return histogram{width: width, counts: make(map[int]int)}
+}
+
+func (h histogram) add(value float64) {
+	h.counts[int(math.Floor(value/h.width))]++
+}
+
+func (h histogram) buckets() []Bucket {
+	keys := make([]int, 0, len(h.counts))
+	for key := range h.counts {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	buckets := make([]Bucket, 0, len(keys))
+	for _, key := range keys {
+		buckets = append(buckets, Bucket{
+			From:  float64(key) * h.width,
+			To:    float64(key+1) * h.width,
+			Count: h.counts[key],
+		})
+	}
+
+	return buckets
+}
+
+// tally accumulates the outcomes of the matches of a definition
+//
+// Only integers are summed, so the report doesn't depend on the order the matches finish in.
+type tally struct {
+	definition Definition
+	matches    int
+	unfinished int
+	wins       map[geometry.Side]int
+	goals      int
+	rallyHits  int
+	longest    int
+	goalTicks  uint64
+	matchTicks uint64
+	topSpeed   float64
+	rallies    histogram
+	speeds     histogram
+}
+
+func newTally(definition Definition) *tally {
+	return &tally{
+		definition: definition,
+		wins:       make(map[geometry.Side]int),
+		rallies:    newHistogram(rallyBucketWidth),
+		speeds:     newHistogram(speedBucketWidth),
+	}
+}
+
+func (t *tally) add(result outcome) {
+	t.matches++
+	t.matchTicks += result.ticks
+
+	if !result.ended {
+		t.unfinished++
+	} else if result.winner != geometry.Undefined {
+		t.wins[result.winner]++
+	}
+
+	for _, rally := range result.rallies {
+		t.goals++
+		t.rallyHits += rally
+		t.longest = max(t.longest, rally)
+		t.rallies.add(float64(rally))
+	}
+
+	for _, ticks := range result.goalTimes {
+		t.goalTicks += ticks
+	}
+
+	for _, speed := range result.hitSpeeds {
+		t.speeds.add(speed)
+	}
+
+	t.topSpeed = max(t.topSpeed, result.stats.TopSpeed)
+}
+
+func (t *tally) report() DefinitionReport {
+	definition := t.definition
+	tickInterval := definition.gameConfig().TickInterval()
+	ms := func(ticks float64) float64 {
+		return ticks * float64(tickInterval) / float64(time.Millisecond)
+	}
+
+	report := DefinitionReport{
+		Name:         definition.Name,
+		Mode:         string(definition.Mode),
+		Level:        definition.Level,
+		Layout:       definition.Layout,
+		Seed:         definition.Seed,
+		Matches:      t.matches,
+		Unfinished:   t.unfinished,
+		Goals:        t.goals,
+		LongestRally: t.longest,
+		TopSpeed:     t.topSpeed,
+		RallyLengths: t.rallies.buckets(),
+		BallSpeeds:   t.speeds.buckets(),
+	}
+
+	if t.goals > 0 {
+		report.AverageRally = float64(t.rallyHits) / float64(t.goals)
+		report.AverageGoalTime = ms(float64(t.goalTicks) / float64(t.goals))
+	}
+
+	if t.matches > 0 {
+		report.AverageDuration = ms(float64(t.matchTicks) / float64(t.matches))
+	}
+
+	for i, side := range definition.Mode.Sides() {
+		sideReport := SideReport{Side: sideName(side), Bot: definition.Bots[i], Wins: t.wins[side]}
+		if t.matches > 0 {
+			sideReport.WinRate = float64(sideReport.Wins) / float64(t.matches)
+		}
+
+		report.Sides = append(report.Sides, sideReport)
+	}
+
+	return report
+}
+
+// writeJSON writes the report as an indented JSON document
+func (r Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+// writeCSV writes the report as definition, metric, key and value rows
+//
+// The keys are the side of the win rates, and the "from-to" range of the histogram buckets.
+func (r Report) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"definition", "metric", "key", "value"}); err != nil {
+		return err
+	}
+
+	number := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	for _, definition := range r.Definitions {
+		rows := [][]string{
+			{"matches", "", strconv.Itoa(definition.Matches)},
+			{"unfinished", "", strconv.Itoa(definition.Unfinished)},
+			{"goals", "", strconv.Itoa(definition.Goals)},
+			{"average_rally", "", number(definition.AverageRally)},
+			{"longest_rally", "", strconv.Itoa(definition.LongestRally)},
+			{"average_goal_time_ms", "", number(definition.AverageGoalTime)},
+			{"average_duration_ms", "", number(definition.AverageDuration)},
+			{"top_speed", "", number(definition.TopSpeed)},
+		}
+
+		for _, side := range definition.Sides {
+			key := fmt.Sprintf("%s:%s", side.Side, side.Bot)
+			rows = append(rows, []string{"wins", key, strconv.Itoa(side.Wins)}, []string{"win_rate", key, number(side.WinRate)})
+		}
+
+		for _, bucket := range definition.RallyLengths {
+			rows = append(rows, []string{"rally_length", number(bucket.From) + "-" + number(bucket.To), strconv.Itoa(bucket.Count)})
+		}
+
+		for _, bucket := range definition.BallSpeeds {
+			rows = append(rows, []string{"ball_speed", number(bucket.From) + "-" + number(bucket.To), strconv.Itoa(bucket.Count)})
+		}
+
+		for _, row := range rows {
+			if err := writer.Write(append([]string{definition.Name}, row...)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func sideName(side geometry.Side) string {
+	switch side {
+	case geometry.Left:
+		return "left"
+	case geometry.Right:
+		return "right"
+	case physics.Top:
+		return "top"
+	case physics.Bottom:
+		return "bottom"
+	default:
+		return "none"
+	}
+}
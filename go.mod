@@ -8,3 +8,5 @@ require (
 )
 
 require github.com/gandarez/pong-multiplayer-go v1.0.0
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,241 @@
+package access
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidBan = errors.New("invalid ban")
+
+// BanKind identifies what a ban is matched against
+type BanKind string
+
+const (
+	BanIP      BanKind = "ip"
+	BanName    BanKind = "name"
+	BanAccount BanKind = "account"
+)
+
+// Ban is a single entry of the ban list
+type Ban struct {
+	Kind      BanKind   `json:"kind"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (b Ban) Validate() error {
+	switch b.Kind {
+	case BanIP, BanName, BanAccount:
+	default:
+		return fmt.Errorf("%w: kind must be one of: ip, name, account", ErrInvalidBan)
+	}
+
+	if normalize(b.Value) == "" {
+		return fmt.Errorf("%w: value is required", ErrInvalidBan)
+	}
+
+	return nil
+}
+
+// BanStore persists the ban list, so bans survive server restarts
+type BanStore interface {
+	Load() ([]Ban, error)
+	Save(bans []Ban) error
+}
+
+// BanList stores the banned IPs, player names and accounts
+//
+// Every change is persisted to the ban store. A nil store keeps the list in memory only.
+type BanList struct {
+	store BanStore
+	bans  map[BanKind]map[string]Ban
+	sync.RWMutex
+}
+
+// NewBanList creates a ban list loaded from the given store
+func NewBanList(store BanStore) (*BanList, error) {
+	list := &BanList{
+		store: store,
+		bans:  make(map[BanKind]map[string]Ban),
+	}
+
+	if store == nil {
+		return list, nil
+	}
+
+	bans, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ban := range bans {
+		if err := ban.Validate(); err != nil {
+			return nil, err
+		}
+		list.set(ban)
+	}
+
+	return list, nil
+}
+
+// Add bans the given value and persists the ban list
+func (b *BanList) Add(ban Ban) error {
+	if err := ban.Validate(); err != nil {
+		return err
+	}
+
+	if ban.CreatedAt.IsZero() {
+		ban.CreatedAt = time.Now()
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.set(ban)
+
+	return b.save()
+}
+
+// Remove lifts a ban and persists the ban list
+//
+// It reports whether the ban existed.
+func (b *BanList) Remove(kind BanKind, value string) (bool, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	value = normalize(value)
+	if _, ok := b.bans[kind][value]; !ok {
+		return false, nil
+	}
+
+	delete(b.bans[kind], value)
+
+	return true, b.save()
+}
+
+// IsBanned reports whether the value is banned for the given kind
+//
+// Empty values are never banned.
+func (b *BanList) IsBanned(kind BanKind, value string) bool {
+	value = normalize(value)
+	if value == "" {
+		return false
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	_, ok := b.bans[kind][value]
+
+	return ok
+}
+
+// List returns every ban sorted by creation time
+func (b *BanList) List() []Ban {
+	b.RLock()
+	defer b.RUnlock()
+
+	bans := make([]Ban, 0)
+	for _, kindBans := range b.bans {
+		for _, ban := range kindBans {
+			bans = append(bans, ban)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedAt.Before(bans[j].CreatedAt)
+	})
+
+	return bans
+}
+
+func (b *BanList) set(ban Ban) {
+	ban.Value = normalize(ban.Value)
+
+	if b.bans[ban.Kind] == nil {
+		b.bans[ban.Kind] = make(map[string]Ban)
+	}
+
+	b.bans[ban.Kind][ban.Value] = ban
+}
+
+func (b *BanList) save() error {
+	if b.store == nil {
+		return nil
+	}
+
+	bans := make([]Ban, 0)
+	for _, kindBans := range b.bans {
+		for _, ban := range kindBans {
+			bans = append(bans, ban)
+		}
+	}
+
+	return b.store.Save(bans)
+}
+
+// FileBanStore persists the ban list as a JSON file
+type FileBanStore struct {
+	Path string
+}
+
+// Load reads the ban list file. A missing file results in an empty ban list.
+func (f FileBanStore) Load() ([]Ban, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading ban list: %w", err)
+	}
+
+	var bans []Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, fmt.Errorf("parsing ban list: %w", err)
+	}
+
+	return bans, nil
+}
+
+// Save writes the ban list to a temporary file and renames it over the
+// previous one, so a crash never leaves a truncated ban list behind
+func (f FileBanStore) Save(bans []Ban) error {
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding ban list: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving ban list: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving ban list: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving ban list: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("saving ban list: %w", err)
+	}
+
+	return nil
+}
+
+// normalize makes bans on names and accounts case insensitive
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,102 @@
+package access
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Proxies are the reverse proxies trusted to report the client IP in the X-Forwarded-For header
+//
+// The header of requests coming from other addresses is ignored, so clients can't spoof
+// their IP to dodge bans and limits.
+type Proxies struct {
+	networks []*net.IPNet
+}
+
+// NewProxies parses the trusted proxies, given as IP addresses or CIDR ranges
+func NewProxies(entries []string) (*Proxies, error) {
+	proxies := &Proxies{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		network, err := ParseNetwork(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		proxies.networks = append(proxies.networks, network)
+	}
+
+	return proxies, nil
+}
+
+// ParseNetwork parses an IP address or a CIDR range, an address being a range of itself
+func ParseNetwork(entry string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return network, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address or CIDR range %q", entry)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// trusted reports whether the address is one of the trusted proxies
+func (p *Proxies) trusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil || p == nil {
+		return false
+	}
+
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the IP address of the client that made the request
+//
+// Requests coming from a trusted proxy are attributed to the last address of their
+// X-Forwarded-For header that isn't a trusted proxy itself.
+func (p *Proxies) ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if !p.trusted(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(address) == nil {
+			break
+		}
+
+		ip = address
+		if !p.trusted(address) {
+			break
+		}
+	}
+
+	return ip
+}
@@ -0,0 +1,49 @@
+package access
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxiesClientIP(t *testing.T) {
+	proxies, err := NewProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct client", "203.0.113.5:4000", nil, "203.0.113.5"},
+		{"spoofed header from client", "203.0.113.5:4000", []string{"198.51.100.7"}, "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:4000", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"chain of trusted proxies", "10.1.2.3:4000", []string{"198.51.100.7, 192.0.2.1"}, "198.51.100.7"},
+		{"spoofed entry before the client", "10.1.2.3:4000", []string{"1.1.1.1, 198.51.100.7"}, "198.51.100.7"},
+		{"several headers", "10.1.2.3:4000", []string{"1.1.1.1", "198.51.100.7"}, "198.51.100.7"},
+		{"trusted proxy without header", "10.1.2.3:4000", nil, "10.1.2.3"},
+		{"invalid entry", "10.1.2.3:4000", []string{"198.51.100.7, garbage"}, "10.1.2.3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/multiplayer", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, value := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := proxies.ClientIP(r); got != test.want {
+				t.Errorf("ClientIP() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewProxiesInvalid(t *testing.T) {
+	if _, err := NewProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("NewProxies() accepted an invalid entry")
+	}
+}
@@ -0,0 +1,60 @@
+package access
+
+import "sync"
+
+// ConnectionLimiter limits the number of concurrent connections per client and in total
+//
+// A non-positive limit disables the respective check.
+type ConnectionLimiter struct {
+	perClient int
+	total     int
+	counts    map[string]int
+	current   int
+	sync.Mutex
+}
+
+func NewConnectionLimiter(perClient, total int) *ConnectionLimiter {
+	return &ConnectionLimiter{
+		perClient: perClient,
+		total:     total,
+		counts:    make(map[string]int),
+	}
+}
+
+// Acquire reserves a connection slot for the given client
+//
+// Every successful call must be paired with a call to Release.
+func (l *ConnectionLimiter) Acquire(key string) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.total > 0 && l.current >= l.total {
+		return false
+	}
+
+	if l.perClient > 0 && l.counts[key] >= l.perClient {
+		return false
+	}
+
+	l.counts[key]++
+	l.current++
+
+	return true
+}
+
+// Release frees a connection slot previously reserved for the given client
+func (l *ConnectionLimiter) Release(key string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.counts[key] == 0 {
+		return
+	}
+
+	l.counts[key]--
+	l.current--
+
+	if l.counts[key] == 0 {
+		delete(l.counts, key)
+	}
+}
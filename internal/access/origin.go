@@ -0,0 +1,74 @@
+package access
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Origins is the allowlist of browser origins allowed to reach the server
+//
+// It's applied both to the CORS headers of the HTTP routes and to the origin
+// check of websocket upgrades. The "*" entry allows any origin. Requests without
+// an Origin header don't come from browsers and are always allowed.
+type Origins struct {
+	allowAll bool
+	origins  map[string]struct{}
+}
+
+func NewOrigins(origins []string) *Origins {
+	allowlist := &Origins{
+		origins: make(map[string]struct{}),
+	}
+
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.ToLower(strings.TrimSpace(origin)), "/")
+		switch origin {
+		case "":
+		case "*":
+			allowlist.allowAll = true
+		default:
+			allowlist.origins[origin] = struct{}{}
+		}
+	}
+
+	return allowlist
+}
+
+// Allowed reports whether the origin is in the allowlist
+func (o *Origins) Allowed(origin string) bool {
+	if o.allowAll {
+		return true
+	}
+
+	_, ok := o.origins[strings.ToLower(origin)]
+
+	return ok
+}
+
+// CheckOrigin is meant to be used as the websocket upgrader origin check
+func (o *Origins) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+
+	return origin == "" || o.Allowed(origin)
+}
+
+// SetCORSHeaders allows the request's origin to read the response when it's in the allowlist
+//
+// When any origin is allowed, the responses are readable by every origin, but without
+// credentials, so other sites can't make credentialed calls on behalf of their visitors.
+func (o *Origins) SetCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	if o.allowAll {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !o.Allowed(origin) {
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+}
@@ -0,0 +1,38 @@
+package access
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginsSetCORSHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		origins         []string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{"any origin", []string{"*"}, "https://evil.example", "*", ""},
+		{"allowed origin", []string{"https://pongo.example"}, "https://pongo.example", "https://pongo.example", "true"},
+		{"refused origin", []string{"https://pongo.example"}, "https://evil.example", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/sessions", nil)
+			r.Header.Set("Origin", test.origin)
+			w := httptest.NewRecorder()
+
+			NewOrigins(test.origins).SetCORSHeaders(w, r)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, test.wantOrigin)
+			}
+
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != test.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, test.wantCredentials)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/chat"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/events"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/httpserver"
+	"github.com/reneepc/pongo-server/internal/lobby"
+	"github.com/reneepc/pongo-server/internal/matchmaking"
+	"github.com/reneepc/pongo-server/internal/physics"
+	"github.com/reneepc/pongo-server/internal/webhook"
+	"github.com/reneepc/pongo-server/internal/ws"
+)
+
+// App is a complete and independent Pongo server
+//
+// It owns every dependency of the server: the session manager, the player pool, the ban
+// list, the event bus, the chat moderator, the lobby and the HTTP routes, and hands them
+// to the handlers and sessions. Multiple apps can run in the same process, e.g. with
+// httptest:
+//
+//	server := httptest.NewServer(app.Handler())
+type App struct {
+	Config     config.Config
+	Sessions   *game.SessionManager
+	PlayerPool *matchmaking.PlayerPool
+	Bans       *access.BanList
+	Events     *events.Bus
+	Chat       *chat.Moderator
+	Lobby      *lobby.Lobby
+	wsServer   *ws.Server
+	httpServer *httpserver.Server
+	webhooks   *webhook.Sink
+}
+
+// Options are the optional dependencies of an App, replacing the default ones
+type Options struct {
+	// Matcher decides which players are matched together, the FIFOMatcher by default
+	Matcher matchmaking.Matcher
+	// BanStore persists the ban list, the configured ban list file by default
+	BanStore access.BanStore
+}
+
+func New(cfg config.Config, opts Options) (*App, error) {
+	if opts.Matcher == nil {
+		opts.Matcher = matchmaking.FIFOMatcher{}
+	}
+
+	if opts.BanStore == nil && cfg.WebSocket.BanListFile != "" {
+		opts.BanStore = access.FileBanStore{Path: cfg.WebSocket.BanListFile}
+	}
+
+	bans, err := access.NewBanList(opts.BanStore)
+	if err != nil {
+		return nil, fmt.Errorf("loading ban list: %w", err)
+	}
+
+	moderator, err := chat.NewModerator(cfg.Chat)
+	if err != nil {
+		return nil, err
+	}
+
+	layouts, err := physics.LoadLayouts(cfg.Game.LayoutsDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading layouts: %w", err)
+	}
+
+	origins := access.NewOrigins(cfg.HTTP.AllowedOrigins)
+	proxies, err := access.NewProxies(cfg.HTTP.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := events.NewBus()
+
+	// The webhook workers are started last, so no fallible step can leave them running
+	var webhooks *webhook.Sink
+	if len(cfg.Webhooks.URLs) > 0 {
+		webhooks, err = webhook.New(cfg.Webhooks)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := webhooks.Start(); err != nil {
+			return nil, err
+		}
+
+		bus.Subscribe("webhooks", webhooks.Handle)
+	}
+
+	sessions := game.NewSessionManager(cfg.Game.MaxTotalSpectators)
+	pool := matchmaking.NewPlayerPool(cfg.Game, layouts, sessions, opts.Matcher, moderator, bus)
+	lobby := lobby.New(sessions, pool)
+	bus.Subscribe("lobby", lobby.Handle)
+
+	wsServer := ws.New(cfg.WebSocket, origins, proxies, bans, pool, sessions, lobby, layouts, bus)
+
+	return &App{
+		Config:     cfg,
+		Sessions:   sessions,
+		PlayerPool: pool,
+		Bans:       bans,
+		Events:     bus,
+		Chat:       moderator,
+		Lobby:      lobby,
+		wsServer:   wsServer,
+		httpServer: httpserver.New(cfg.HTTP, origins, wsServer, sessions, moderator),
+		webhooks:   webhooks,
+	}, nil
+}
+
+// Handler returns the handler serving every route of the app
+func (a *App) Handler() http.Handler {
+	return a.httpServer.Handler()
+}
+
+// Start listens on the configured port, serving TLS when configured, until the app is shut down
+func (a *App) Start() error {
+	addr := fmt.Sprintf("0.0.0.0:%s", a.Config.HTTP.Port)
+
+	if a.Config.HTTP.TLS() {
+		return a.httpServer.StartTLS(addr, a.Config.HTTP.TLSCertFile, a.Config.HTTP.TLSKeyFile)
+	}
+
+	return a.httpServer.Start(addr)
+}
+
+// Shutdown disconnects the players in the queue, ends the running sessions,
+// gracefully shuts down the HTTP server, flushes the events to the subscribers and
+// disconnects the lobby clients
+//
+// Webhook deliveries still pending are kept in the outbox for the next run.
+func (a *App) Shutdown() error {
+	a.PlayerPool.Stop()
+
+	for _, session := range a.Sessions.GetSessions() {
+		slog.Info("Ending session on shutdown", slog.String("session_id", session.ID))
+		session.ForceEnd(geometry.Undefined)
+	}
+
+	err := a.httpServer.Shutdown()
+
+	a.Events.Close()
+	a.Lobby.Close()
+
+	if a.webhooks != nil {
+		a.webhooks.Close()
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/game"
+)
+
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.WebSocket.BanListFile = ""
+
+	a, err := New(cfg, Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return a
+}
+
+func TestAppsAreIndependent(t *testing.T) {
+	first, second := newTestApp(t), newTestApp(t)
+
+	if first.Sessions == second.Sessions || first.PlayerPool == second.PlayerPool {
+		t.Fatal("apps share their session manager or player pool")
+	}
+
+	server := httptest.NewServer(first.Handler())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/multiplayer", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	info := game.GameInfo{PlayerName: "alice", Mode: game.ModeClassic, ScreenWidth: 640, ScreenHeight: 480, FieldBorderWidth: 10, MaxScore: 5}
+	if err := conn.WriteJSON(info); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(first.PlayerPool.Waiting()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("player never joined the queue of its app")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if waiting := second.PlayerPool.Waiting(); len(waiting) != 0 {
+		t.Errorf("other app queue has %d players, want 0", len(waiting))
+	}
+
+	for _, a := range []*App{first, second} {
+		if err := a.Shutdown(); err != nil {
+			t.Errorf("Shutdown() error = %v", err)
+		}
+	}
+
+	// Stopping the pool again must not panic
+	first.PlayerPool.Stop()
+
+	if waiting := first.PlayerPool.Waiting(); len(waiting) != 0 {
+		t.Errorf("queue has %d players after shutdown, want 0", len(waiting))
+	}
+}
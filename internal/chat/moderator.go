@@ -0,0 +1,276 @@
+package chat
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/config"
+)
+
+var (
+	ErrDisabled    = errors.New("chat is disabled")
+	ErrAnonymous   = errors.New("a name is required to chat")
+	ErrEmpty       = errors.New("message is empty")
+	ErrTooLong     = errors.New("message is too long")
+	ErrRateLimited = errors.New("sending messages too fast")
+	ErrMuted       = errors.New("muted by the server")
+	ErrInvalidMute = errors.New("invalid mute")
+)
+
+// MuteKind identifies what a mute is matched against
+type MuteKind string
+
+const (
+	MuteName    MuteKind = "name"
+	MuteAccount MuteKind = "account"
+	MuteIP      MuteKind = "ip"
+)
+
+// Mute silences a sender in every chat, until the given time or permanently when it's zero
+//
+// Names are chosen by the clients, which can change them at will, so account and IP
+// mutes are preferred where available.
+type Mute struct {
+	Kind      MuteKind  `json:"kind"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason,omitempty"`
+	Until     time.Time `json:"until,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m Mute) expired(now time.Time) bool {
+	return !m.Until.IsZero() && now.After(m.Until)
+}
+
+// Sender is the client sending a chat message, its account and IP being empty when unknown
+type Sender struct {
+	ID        string
+	Name      string
+	AccountID string
+	IP        string
+}
+
+// Moderator checks the chat messages before they are delivered
+//
+// Messages are refused when the chat is disabled, when they are empty or too long,
+// when their sender exceeds the message rate or its name, account or IP is muted by the
+// server. Words in the blocklist are masked, ignoring case.
+type Moderator struct {
+	enabled   bool
+	maxLength int
+	limiter   *access.RateLimiter
+	blocklist *regexp.Regexp
+	mutes     map[MuteKind]map[string]Mute
+	sync.Mutex
+}
+
+// NewModerator creates the chat moderator, reading the blocklist file if configured
+func NewModerator(cfg config.Chat) (*Moderator, error) {
+	words := cfg.Blocklist
+
+	if cfg.BlocklistFile != "" {
+		fileWords, err := readBlocklist(cfg.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+
+		words = append(words, fileWords...)
+	}
+
+	moderator := &Moderator{
+		enabled:   cfg.Enabled,
+		maxLength: cfg.MaxLength,
+		limiter:   access.NewRateLimiter(cfg.Rate, cfg.Burst),
+		mutes:     make(map[MuteKind]map[string]Mute),
+	}
+
+	if len(words) > 0 {
+		quoted := make([]string, 0, len(words))
+		for _, word := range words {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+
+		moderator.blocklist = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	}
+
+	return moderator, nil
+}
+
+// Check validates a message sent by the client, returning the filtered text
+func (m *Moderator) Check(sender Sender, text string) (string, error) {
+	if !m.enabled {
+		return "", ErrDisabled
+	}
+
+	if sender.Name == "" {
+		return "", ErrAnonymous
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ErrEmpty
+	}
+
+	if utf8.RuneCountInString(text) > m.maxLength {
+		return "", ErrTooLong
+	}
+
+	if m.muted(sender) {
+		return "", ErrMuted
+	}
+
+	if !m.limiter.Allow(sender.ID) {
+		return "", ErrRateLimited
+	}
+
+	return m.filter(text), nil
+}
+
+// Mute silences a name, account or IP, replacing any previous mute of the same value
+func (m *Moderator) Mute(mute Mute) error {
+	switch mute.Kind {
+	case MuteName, MuteAccount, MuteIP:
+	default:
+		return fmt.Errorf("%w: kind must be one of: name, account, ip", ErrInvalidMute)
+	}
+
+	mute.Value = strings.ToLower(strings.TrimSpace(mute.Value))
+	if mute.Value == "" {
+		return fmt.Errorf("%w: value is required", ErrInvalidMute)
+	}
+
+	if mute.CreatedAt.IsZero() {
+		mute.CreatedAt = time.Now()
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.mutes[mute.Kind] == nil {
+		m.mutes[mute.Kind] = make(map[string]Mute)
+	}
+
+	m.mutes[mute.Kind][mute.Value] = mute
+
+	return nil
+}
+
+// Unmute lifts the mute of a name, account or IP, reporting whether it was muted
+func (m *Moderator) Unmute(kind MuteKind, value string) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	value = strings.ToLower(value)
+
+	_, ok := m.mutes[kind][value]
+	delete(m.mutes[kind], value)
+
+	return ok
+}
+
+// Mutes returns the active mutes sorted by kind and value
+func (m *Moderator) Mutes() []Mute {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+
+	mutes := make([]Mute, 0)
+	for _, values := range m.mutes {
+		for value, mute := range values {
+			if mute.expired(now) {
+				delete(values, value)
+				continue
+			}
+
+			mutes = append(mutes, mute)
+		}
+	}
+
+	sort.Slice(mutes, func(i, j int) bool {
+		if mutes[i].Kind != mutes[j].Kind {
+			return mutes[i].Kind < mutes[j].Kind
+		}
+
+		return mutes[i].Value < mutes[j].Value
+	})
+
+	return mutes
+}
+
+// muted reports whether the sender's name, account or IP is muted
+func (m *Moderator) muted(sender Sender) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+
+	values := map[MuteKind]string{
+		MuteName:    sender.Name,
+		MuteAccount: sender.AccountID,
+		MuteIP:      sender.IP,
+	}
+	for kind, value := range values {
+		if value == "" {
+			continue
+		}
+
+		value = strings.ToLower(value)
+
+		mute, ok := m.mutes[kind][value]
+		if !ok {
+			continue
+		}
+
+		if mute.expired(now) {
+			delete(m.mutes[kind], value)
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (m *Moderator) filter(text string) string {
+	if m.blocklist == nil {
+		return text
+	}
+
+	return m.blocklist.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+}
+
+func readBlocklist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading chat blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading chat blocklist: %w", err)
+	}
+
+	return words, nil
+}
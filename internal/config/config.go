@@ -0,0 +1,327 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/access"
+)
+
+// Config is the complete server configuration
+//
+// Every setting can be given in a YAML file, an environment variable or a command line
+// flag, in increasing order of precedence. Settings that are not given keep the defaults
+// returned by Default.
+type Config struct {
+	HTTP      HTTP      `yaml:"http"`
+	WebSocket WebSocket `yaml:"websocket"`
+	Game      Game      `yaml:"game"`
+	Webhooks  Webhooks  `yaml:"webhooks"`
+	Chat      Chat      `yaml:"chat"`
+}
+
+// HTTP configures the HTTP server and its routes
+type HTTP struct {
+	Port            string        `yaml:"port" env:"PORT" flag:"port" usage:"Port the server listens on"`
+	AdminToken      string        `yaml:"admin_token" env:"ADMIN_TOKEN" flag:"admin-token" usage:"Bearer token of the admin routes, which are disabled when empty"`
+	AllowedOrigins  []string      `yaml:"allowed_origins" env:"ALLOWED_ORIGINS" flag:"allowed-origins" usage:"Comma separated browser origins allowed to connect, * allows any"`
+	TrustedProxies  []string      `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" flag:"trusted-proxies" usage:"Comma separated IPs or CIDR ranges of the reverse proxies whose X-Forwarded-For header is trusted"`
+	TLSCertFile     string        `yaml:"tls_cert_file" env:"TLS_CERT_FILE" flag:"tls-cert-file" usage:"TLS certificate file, enables TLS together with the key file"`
+	TLSKeyFile      string        `yaml:"tls_key_file" env:"TLS_KEY_FILE" flag:"tls-key-file" usage:"TLS key file, enables TLS together with the certificate file"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT" flag:"read-timeout" usage:"Maximum duration for reading an HTTP request"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT" flag:"write-timeout" usage:"Maximum duration for writing an HTTP response"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" flag:"idle-timeout" usage:"Maximum duration an idle keep-alive connection is kept open"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"Maximum duration of the graceful shutdown"`
+}
+
+// WebSocket configures the players and spectators connections
+type WebSocket struct {
+	HandshakeTimeout   time.Duration `yaml:"handshake_timeout" env:"HANDSHAKE_TIMEOUT" flag:"handshake-timeout" usage:"Time a client has to send its first message after connecting"`
+	PingInterval       time.Duration `yaml:"ping_interval" env:"PING_INTERVAL" flag:"ping-interval" usage:"Interval between latency measurement pings"`
+	ConnectionRate     float64       `yaml:"connection_rate" env:"CONNECTION_RATE" flag:"connection-rate" usage:"Connections per second allowed per IP, 0 disables the limit"`
+	ConnectionBurst    int           `yaml:"connection_burst" env:"CONNECTION_BURST" flag:"connection-burst" usage:"Connections allowed per IP in a burst"`
+	MaxPlayersPerIP    int           `yaml:"max_players_per_ip" env:"MAX_PLAYERS_PER_IP" flag:"max-players-per-ip" usage:"Concurrent player connections per IP, 0 disables the limit"`
+	MaxPlayers         int           `yaml:"max_players" env:"MAX_PLAYERS" flag:"max-players" usage:"Concurrent player connections, 0 disables the limit"`
+	MaxSpectatorsPerIP int           `yaml:"max_spectators_per_ip" env:"MAX_SPECTATORS_PER_IP" flag:"max-spectators-per-ip" usage:"Concurrent spectator connections per IP, 0 disables the limit"`
+	MaxSpectators      int           `yaml:"max_spectators" env:"MAX_SPECTATORS" flag:"max-spectators" usage:"Concurrent spectator connections, 0 disables the limit"`
+	BanListFile        string        `yaml:"ban_list_file" env:"BAN_LIST_FILE" flag:"ban-list-file" usage:"File the ban list is persisted to, empty keeps it in memory"`
+}
+
+// Game configures the game sessions
+type Game struct {
+	TickRate                 int           `yaml:"tick_rate" env:"TICK_RATE" flag:"tick-rate" usage:"Game loop updates per second"`
+	InputQueueSize           int           `yaml:"input_queue_size" env:"INPUT_QUEUE_SIZE" flag:"input-queue-size" usage:"Player inputs buffered between game loop updates"`
+	PaddleSpeed              float64       `yaml:"paddle_speed" env:"PADDLE_SPEED" flag:"paddle-speed" usage:"Paddle movement per update"`
+	SpectatorDelay           time.Duration `yaml:"spectator_delay" env:"SPECTATOR_DELAY" flag:"spectator-delay" usage:"Delay of the game states streamed to spectators"`
+	TournamentSpectatorDelay time.Duration `yaml:"tournament_spectator_delay" env:"TOURNAMENT_SPECTATOR_DELAY" flag:"tournament-spectator-delay" usage:"Minimum spectator delay of tournament sessions"`
+	TournamentAccounts       []string      `yaml:"tournament_accounts" env:"TOURNAMENT_ACCOUNTS" flag:"tournament-accounts" usage:"Comma separated account IDs of the players competing in tournaments"`
+	MaxSpectatorsPerSession  int           `yaml:"max_spectators_per_session" env:"MAX_SPECTATORS_PER_SESSION" flag:"max-spectators-per-session" usage:"Spectators watching a single session, 0 disables the limit"`
+	MaxTotalSpectators       int           `yaml:"max_total_spectators" env:"MAX_TOTAL_SPECTATORS" flag:"max-total-spectators" usage:"Spectators watching any session, 0 disables the limit"`
+	EmoteRate                float64       `yaml:"emote_rate" env:"EMOTE_RATE" flag:"emote-rate" usage:"Emotes per second allowed per player, 0 disables the limit"`
+	EmoteBurst               int           `yaml:"emote_burst" env:"EMOTE_BURST" flag:"emote-burst" usage:"Emotes allowed per player in a burst"`
+	ArenaLives               int           `yaml:"arena_lives" env:"ARENA_LIVES" flag:"arena-lives" usage:"Goals a player concedes before being eliminated in the arena mode"`
+	LayoutsDir               string        `yaml:"layouts_dir" env:"LAYOUTS_DIR" flag:"layouts-dir" usage:"Directory of the JSON field layouts players can choose, empty disables layouts"`
+	MultiballBalls           int           `yaml:"multiball_balls" env:"MULTIBALL_BALLS" flag:"multiball-balls" usage:"Balls in play in the multiball mode"`
+	ServeRule                string        `yaml:"serve_rule" env:"SERVE_RULE" flag:"serve-rule" usage:"Side the ball is served toward after a goal: conceder, alternate or random"`
+	ServeDelay               time.Duration `yaml:"serve_delay" env:"SERVE_DELAY" flag:"serve-delay" usage:"Time the ball waits in the center before being served after a goal"`
+	ServeByButton            bool          `yaml:"serve_by_button" env:"SERVE_BY_BUTTON" flag:"serve-by-button" usage:"Waits for the serving player to press serve before serving the ball"`
+	ServeTimeout             time.Duration `yaml:"serve_timeout" env:"SERVE_TIMEOUT" flag:"serve-timeout" usage:"Time the serving player has to press serve before the ball is served anyway"`
+	PowerUpInterval          time.Duration `yaml:"power_up_interval" env:"POWER_UP_INTERVAL" flag:"power-up-interval" usage:"Average time between power-up spawns, 0 disables power-ups"`
+	PowerUpDuration          time.Duration `yaml:"power_up_duration" env:"POWER_UP_DURATION" flag:"power-up-duration" usage:"Duration of the paddle and shield power-up effects"`
+}
+
+// Webhooks configures the delivery of the server events to external HTTP endpoints
+type Webhooks struct {
+	URLs        []string      `yaml:"urls" env:"WEBHOOK_URLS" flag:"webhook-urls" usage:"Comma separated URLs the events are posted to, empty disables webhooks"`
+	Secret      string        `yaml:"secret" env:"WEBHOOK_SECRET" flag:"webhook-secret" usage:"Secret used to sign the webhook requests, required with webhook URLs"`
+	Events      []string      `yaml:"events" env:"WEBHOOK_EVENTS" flag:"webhook-events" usage:"Comma separated event types to deliver, empty delivers every event"`
+	MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" flag:"webhook-max-attempts" usage:"Delivery attempts before a webhook request is given up"`
+	Timeout     time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" flag:"webhook-timeout" usage:"Timeout of each webhook request"`
+	OutboxDir   string        `yaml:"outbox_dir" env:"WEBHOOK_OUTBOX_DIR" flag:"webhook-outbox-dir" usage:"Directory pending deliveries are persisted to, empty keeps them in memory"`
+}
+
+// maxSpectatorDelay bounds the game states each session buffers for its spectators
+const maxSpectatorDelay = 5 * time.Minute
+
+// Chat configures the lobby, match and spectator chats and their moderation
+type Chat struct {
+	Enabled       bool     `yaml:"enabled" env:"CHAT_ENABLED" flag:"chat-enabled" usage:"Enables the lobby, match and spectator chats"`
+	MaxLength     int      `yaml:"max_length" env:"CHAT_MAX_LENGTH" flag:"chat-max-length" usage:"Maximum characters of a chat message"`
+	Rate          float64  `yaml:"rate" env:"CHAT_RATE" flag:"chat-rate" usage:"Chat messages per second allowed per client, 0 disables the limit"`
+	Burst         int      `yaml:"burst" env:"CHAT_BURST" flag:"chat-burst" usage:"Chat messages allowed per client in a burst"`
+	Blocklist     []string `yaml:"blocklist" env:"CHAT_BLOCKLIST" flag:"chat-blocklist" usage:"Comma separated words masked in chat messages"`
+	BlocklistFile string   `yaml:"blocklist_file" env:"CHAT_BLOCKLIST_FILE" flag:"chat-blocklist-file" usage:"File with a word masked in chat messages per line"`
+}
+
+// Default returns the configuration used when no setting is given
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Port:            "8080",
+			AllowedOrigins:  []string{"*"},
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 60 * time.Second,
+		},
+		WebSocket: WebSocket{
+			HandshakeTimeout:   5 * time.Second,
+			PingInterval:       5 * time.Second,
+			ConnectionRate:     1,
+			ConnectionBurst:    5,
+			MaxPlayersPerIP:    4,
+			MaxPlayers:         1000,
+			MaxSpectatorsPerIP: 8,
+			MaxSpectators:      5000,
+			BanListFile:        "bans.json",
+		},
+		Game: Game{
+			TickRate:                 60,
+			InputQueueSize:           100,
+			PaddleSpeed:              4,
+			SpectatorDelay:           2 * time.Second,
+			TournamentSpectatorDelay: 30 * time.Second,
+			MaxSpectatorsPerSession:  500,
+			MaxTotalSpectators:       4000,
+			EmoteRate:                0.5,
+			EmoteBurst:               3,
+			ArenaLives:               3,
+			MultiballBalls:           3,
+			ServeRule:                "conceder",
+			ServeDelay:               time.Second,
+			ServeTimeout:             10 * time.Second,
+			PowerUpInterval:          10 * time.Second,
+			PowerUpDuration:          8 * time.Second,
+		},
+		Webhooks: Webhooks{
+			MaxAttempts: 8,
+			Timeout:     10 * time.Second,
+			OutboxDir:   "webhook-outbox",
+		},
+		Chat: Chat{
+			Enabled:   true,
+			MaxLength: 200,
+			Rate:      1,
+			Burst:     5,
+		},
+	}
+}
+
+// TLS reports whether the server should serve TLS directly
+func (h HTTP) TLS() bool {
+	return h.TLSCertFile != "" && h.TLSKeyFile != ""
+}
+
+// TickInterval is the duration of a single game loop update
+func (g Game) TickInterval() time.Duration {
+	return time.Second / time.Duration(g.TickRate)
+}
+
+// TournamentAccount reports whether the account ID is one of the players competing in tournaments
+func (g Game) TournamentAccount(accountID string) bool {
+	return accountID != "" && slices.Contains(g.TournamentAccounts, accountID)
+}
+
+// SpectatorDelayFor returns the spectator delay of a session, which is at least the
+// tournament delay for tournament sessions
+func (g Game) SpectatorDelayFor(tournament bool) time.Duration {
+	if tournament {
+		return max(g.SpectatorDelay, g.TournamentSpectatorDelay)
+	}
+
+	return g.SpectatorDelay
+}
+
+// Validate returns every invalid setting of the configuration
+func (c Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("http.port must be a number between 1 and 65535, got %q", c.HTTP.Port))
+	}
+
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		errs = append(errs, errors.New("http.tls_cert_file and http.tls_key_file must be set together"))
+	}
+
+	for _, proxy := range c.HTTP.TrustedProxies {
+		if _, err := access.ParseNetwork(strings.TrimSpace(proxy)); err != nil && strings.TrimSpace(proxy) != "" {
+			errs = append(errs, fmt.Errorf("http.trusted_proxies: %w", err))
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"http.read_timeout":           c.HTTP.ReadTimeout,
+		"http.write_timeout":          c.HTTP.WriteTimeout,
+		"http.idle_timeout":           c.HTTP.IdleTimeout,
+		"http.shutdown_timeout":       c.HTTP.ShutdownTimeout,
+		"websocket.handshake_timeout": c.WebSocket.HandshakeTimeout,
+		"websocket.ping_interval":     c.WebSocket.PingInterval,
+	}
+	for name, duration := range durations {
+		if duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", name, duration))
+		}
+	}
+
+	if c.WebSocket.ConnectionRate < 0 {
+		errs = append(errs, fmt.Errorf("websocket.connection_rate must not be negative, got %v", c.WebSocket.ConnectionRate))
+	}
+
+	if c.WebSocket.ConnectionRate > 0 && c.WebSocket.ConnectionBurst < 1 {
+		errs = append(errs, fmt.Errorf("websocket.connection_burst must be at least 1, got %d", c.WebSocket.ConnectionBurst))
+	}
+
+	limits := map[string]int{
+		"websocket.max_players_per_ip":    c.WebSocket.MaxPlayersPerIP,
+		"websocket.max_players":           c.WebSocket.MaxPlayers,
+		"websocket.max_spectators_per_ip": c.WebSocket.MaxSpectatorsPerIP,
+		"websocket.max_spectators":        c.WebSocket.MaxSpectators,
+		"game.max_spectators_per_session": c.Game.MaxSpectatorsPerSession,
+		"game.max_total_spectators":       c.Game.MaxTotalSpectators,
+	}
+	for name, limit := range limits {
+		if limit < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %d", name, limit))
+		}
+	}
+
+	if c.Game.TickRate < 1 || c.Game.TickRate > 1000 {
+		errs = append(errs, fmt.Errorf("game.tick_rate must be between 1 and 1000, got %d", c.Game.TickRate))
+	}
+
+	if c.Game.InputQueueSize < 1 {
+		errs = append(errs, fmt.Errorf("game.input_queue_size must be at least 1, got %d", c.Game.InputQueueSize))
+	}
+
+	if c.Game.PaddleSpeed <= 0 {
+		errs = append(errs, fmt.Errorf("game.paddle_speed must be positive, got %v", c.Game.PaddleSpeed))
+	}
+
+	delays := map[string]time.Duration{
+		"game.spectator_delay":            c.Game.SpectatorDelay,
+		"game.tournament_spectator_delay": c.Game.TournamentSpectatorDelay,
+	}
+	for name, delay := range delays {
+		if delay < 0 || delay > maxSpectatorDelay {
+			errs = append(errs, fmt.Errorf("%s must be between 0s and %s, got %s", name, maxSpectatorDelay, delay))
+		}
+	}
+
+	if c.Game.ArenaLives < 1 || c.Game.ArenaLives > 127 {
+		errs = append(errs, fmt.Errorf("game.arena_lives must be between 1 and 127, got %d", c.Game.ArenaLives))
+	}
+
+	if c.Game.MultiballBalls < 2 || c.Game.MultiballBalls > 8 {
+		errs = append(errs, fmt.Errorf("game.multiball_balls must be between 2 and 8, got %d", c.Game.MultiballBalls))
+	}
+
+	switch c.Game.ServeRule {
+	case "conceder", "alternate", "random":
+	default:
+		errs = append(errs, fmt.Errorf("game.serve_rule must be one of conceder, alternate or random, got %q", c.Game.ServeRule))
+	}
+
+	if c.Game.ServeDelay < 0 {
+		errs = append(errs, fmt.Errorf("game.serve_delay must not be negative, got %s", c.Game.ServeDelay))
+	}
+
+	if c.Game.ServeByButton && c.Game.ServeTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("game.serve_timeout must be positive, got %s", c.Game.ServeTimeout))
+	}
+
+	if c.Game.PowerUpInterval < 0 {
+		errs = append(errs, fmt.Errorf("game.power_up_interval must not be negative, got %s", c.Game.PowerUpInterval))
+	}
+
+	if c.Game.PowerUpInterval > 0 && c.Game.PowerUpDuration <= 0 {
+		errs = append(errs, fmt.Errorf("game.power_up_duration must be positive, got %s", c.Game.PowerUpDuration))
+	}
+
+	if c.Game.EmoteRate < 0 {
+		errs = append(errs, fmt.Errorf("game.emote_rate must not be negative, got %v", c.Game.EmoteRate))
+	}
+
+	if c.Game.EmoteRate > 0 && c.Game.EmoteBurst < 1 {
+		errs = append(errs, fmt.Errorf("game.emote_burst must be at least 1, got %d", c.Game.EmoteBurst))
+	}
+
+	if len(c.Webhooks.URLs) > 0 {
+		for _, rawURL := range c.Webhooks.URLs {
+			if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("webhooks.urls must be http or https URLs, got %q", rawURL))
+			}
+		}
+
+		if c.Webhooks.Secret == "" {
+			errs = append(errs, errors.New("webhooks.secret is required to sign the webhook requests"))
+		}
+	}
+
+	if c.Chat.MaxLength < 1 {
+		errs = append(errs, fmt.Errorf("chat.max_length must be at least 1, got %d", c.Chat.MaxLength))
+	}
+
+	if c.Chat.Rate < 0 {
+		errs = append(errs, fmt.Errorf("chat.rate must not be negative, got %v", c.Chat.Rate))
+	}
+
+	if c.Chat.Rate > 0 && c.Chat.Burst < 1 {
+		errs = append(errs, fmt.Errorf("chat.burst must be at least 1, got %d", c.Chat.Burst))
+	}
+
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("webhooks.max_attempts must be at least 1, got %d", c.Webhooks.MaxAttempts))
+	}
+
+	if c.Webhooks.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.timeout must be positive, got %s", c.Webhooks.Timeout))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a single configuration field along with its environment variable and flag names
+type setting struct {
+	value reflect.Value
+	env   string
+	flag  string
+	usage string
+}
+
+// Load builds the configuration from the defaults, the YAML file, the environment
+// variables and the command line arguments, in this order of precedence
+//
+// The YAML file is given by the -config flag or the CONFIG_FILE environment variable.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+	settings := settingsOf(&cfg)
+
+	flags := flag.NewFlagSet("pongo-server", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file (env CONFIG_FILE)")
+	for _, s := range settings {
+		s.register(flags)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	// Flags are parsed first to find the configuration file, so the values given
+	// on the command line are kept aside and applied again after the file and the
+	// environment variables are loaded.
+	given := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if err := s.loadEnv(); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for name, value := range given {
+		if err := flags.Set(name, value); err != nil {
+			return Config{}, fmt.Errorf("invalid value %q for flag -%s: %w", value, name, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading configuration file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// settingsOf lists every field of the configuration sections
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := range sections.NumField() {
+		section := sections.Field(i)
+		for j := range section.NumField() {
+			field := section.Type().Field(j)
+			settings = append(settings, setting{
+				value: section.Field(j),
+				env:   field.Tag.Get("env"),
+				flag:  field.Tag.Get("flag"),
+				usage: field.Tag.Get("usage"),
+			})
+		}
+	}
+
+	return settings
+}
+
+func (s setting) register(flags *flag.FlagSet) {
+	usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+
+	switch p := s.value.Addr().Interface().(type) {
+	case *string:
+		flags.StringVar(p, s.flag, *p, usage)
+	case *bool:
+		flags.BoolVar(p, s.flag, *p, usage)
+	case *int:
+		flags.IntVar(p, s.flag, *p, usage)
+	case *float64:
+		flags.Float64Var(p, s.flag, *p, usage)
+	case *time.Duration:
+		flags.DurationVar(p, s.flag, *p, usage)
+	case *[]string:
+		flags.Var((*listValue)(p), s.flag, usage)
+	default:
+		panic(fmt.Sprintf("unsupported configuration type %T", p))
+	}
+}
+
+func (s setting) loadEnv() error {
+	raw, ok := os.LookupEnv(s.env)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	var err error
+	switch p := s.value.Addr().Interface().(type) {
+	case *string:
+		*p = raw
+	case *bool:
+		*p, err = strconv.ParseBool(raw)
+	case *int:
+		*p, err = strconv.Atoi(raw)
+	case *float64:
+		*p, err = strconv.ParseFloat(raw, 64)
+	case *time.Duration:
+		*p, err = time.ParseDuration(raw)
+	case *[]string:
+		err = (*listValue)(p).Set(raw)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value %q for environment variable %s: %w", raw, s.env, err)
+	}
+
+	return nil
+}
+
+// listValue is a comma separated list flag
+type listValue []string
+
+func (l *listValue) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type identifies the kind of an event
+type Type string
+
+const (
+	PlayerJoined       Type = "player.joined"
+	PlayerLeft         Type = "player.left"
+	PlayerQueued       Type = "queue.player_queued"
+	PlayersMatched     Type = "queue.players_matched"
+	QueueChanged       Type = "queue.size_changed"
+	MatchStarted       Type = "match.started"
+	GoalScored         Type = "match.goal_scored"
+	PlayerDisconnected Type = "match.player_disconnected"
+	PlayerEliminated   Type = "match.player_eliminated"
+	MatchEnded         Type = "match.ended"
+)
+
+// subscriberQueueSize is how many events a subscriber may fall behind before events are dropped
+const subscriberQueueSize = 1024
+
+// Event is something that happened in the server
+//
+// The ID is unique per event, allowing receivers to discard duplicates. The
+// SessionID is only set for events related to a game session, and the Data
+// holds the event specific payload.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id,omitempty"`
+	Data      any       `json:"data"`
+}
+
+func New(eventType Type, sessionID string, data any) Event {
+	return Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Time:      time.Now(),
+		SessionID: sessionID,
+		Data:      data,
+	}
+}
+
+type subscriber struct {
+	name  string
+	queue chan Event
+	done  chan struct{}
+}
+
+// Bus fans out the server events to its subscribers
+//
+// Publishing never blocks: each subscriber has its own queue and goroutine, receiving
+// the events in the order they were published. Events are dropped for subscribers
+// that fall too far behind, so a slow subscriber never stalls a game loop.
+//
+// A nil Bus is valid and discards every event.
+type Bus struct {
+	subscribers []*subscriber
+	closed      bool
+	sync.RWMutex
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls the handler for every event published from now on
+func (b *Bus) Subscribe(name string, handler func(Event)) {
+	s := &subscriber{
+		name:  name,
+		queue: make(chan Event, subscriberQueueSize),
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+
+		for event := range s.queue {
+			handler(event)
+		}
+	}()
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Publish sends the event to every subscriber
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, s := range b.subscribers {
+		select {
+		case s.queue <- event:
+		default:
+			slog.Warn("Event dropped, subscriber is behind", slog.String("subscriber", s.name), slog.Any("type", event.Type))
+		}
+	}
+}
+
+// Close stops accepting events and waits for the subscribers to handle the queued ones
+func (b *Bus) Close() {
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return
+	}
+
+	b.closed = true
+	for _, s := range b.subscribers {
+		close(s.queue)
+	}
+	b.Unlock()
+
+	for _, s := range b.subscribers {
+		<-s.done
+	}
+}
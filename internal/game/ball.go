@@ -0,0 +1,65 @@
+package game
+
+import (
+	"slices"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// ball is a ball in play, with the player whose paddle hit it last since it was served
+// and the length of its rally
+//
+// The ball stays in the center of the field until it's launched toward the serving side,
+// on its launch tick, or once a player of the serving side presses serve when the button
+// is required. Extra balls are put in play by power-ups, and they leave the play once
+// they score.
+type ball struct {
+	*physics.Ball
+	id      int
+	extra   bool
+	lastHit *Player
+	serve   serve
+	// rally is the number of paddle hits since the ball was served
+	rally int
+}
+
+// addBall puts a new ball in play, served from the center of the field on the launch tick
+//
+// The extra balls are launched without waiting for the serve button.
+func (session *GameSession) addBall(extra bool, launch uint64) {
+	session.lastBallID++
+
+	b := &ball{
+		Ball:  physics.NewBall(session.field, session.level, session.rand),
+		id:    session.lastBallID,
+		extra: extra,
+	}
+	session.serveBall(b, session.serveSide(geometry.Undefined), launch, !extra && session.serve.button)
+
+	session.balls = append(session.balls, b)
+}
+
+// resetBall serves the ball again after it scored on the goal side, or removes it from play
+// when it's an extra ball
+func (session *GameSession) resetBall(b *ball, goalSide geometry.Side) {
+	if b.extra {
+		session.balls = slices.DeleteFunc(session.balls, func(other *ball) bool { return other == b })
+		return
+	}
+
+	b.lastHit = nil
+	session.serveBall(b, session.serveSide(goalSide), session.tick+session.serve.delay, session.serve.button)
+}
+
+// extraBalls returns the number of extra balls in play
+func (session *GameSession) extraBalls() int {
+	extra := 0
+	for _, b := range session.balls {
+		if b.extra {
+			extra++
+		}
+	}
+
+	return extra
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/chat"
+)
+
+// ChatChannel is where a chat message was sent
+type ChatChannel string
+
+const (
+	// ChannelLobby is shared by the players waiting in the match queue
+	ChannelLobby ChatChannel = "lobby"
+	// ChannelMatch is shared by the players of a session
+	ChannelMatch ChatChannel = "match"
+	// ChannelSpectators is shared by the spectators of a session
+	ChannelSpectators ChatChannel = "spectators"
+)
+
+// ChatMessage is sent to the clients of a chat channel when one of them chats
+type ChatMessage struct {
+	Chat ChatEntry `json:"chat"`
+}
+
+type ChatEntry struct {
+	Channel ChatChannel `json:"channel"`
+	From    string      `json:"from"`
+	Text    string      `json:"text"`
+	Time    time.Time   `json:"time"`
+}
+
+// ChatRejectedMessage is sent to a client whose chat message was refused, with the reason
+type ChatRejectedMessage struct {
+	ChatRejected string `json:"chat_rejected"`
+}
+
+// Chat moderates a chat message and delivers it to the recipients, including the sender
+//
+// Recipients that muted the sender don't receive the message, and the sender is told
+// when its message is refused.
+func Chat(moderator *chat.Moderator, channel ChatChannel, sender *Network, text string, recipients []*Network, logAttrs ...any) {
+	filtered, err := moderator.Check(chat.Sender{
+		ID:        sender.ID,
+		Name:      sender.PlayerName,
+		AccountID: sender.AccountID,
+		IP:        sender.IP,
+	}, text)
+	if err != nil {
+		slog.Warn("Chat message refused", append(logAttrs, slog.Any("channel", channel), slog.String("from", sender.PlayerName), slog.Any("reason", err))...)
+		sender.Send(ChatRejectedMessage{ChatRejected: err.Error()})
+		return
+	}
+
+	slog.Info("Chat message", append(logAttrs, slog.Any("channel", channel), slog.String("from", sender.PlayerName), slog.String("text", filtered))...)
+
+	message := ChatMessage{Chat: ChatEntry{
+		Channel: channel,
+		From:    sender.PlayerName,
+		Text:    filtered,
+		Time:    time.Now(),
+	}}
+
+	for _, recipient := range recipients {
+		if recipient != sender && recipient.muted(sender.PlayerName) {
+			continue
+		}
+
+		recipient.Send(message)
+	}
+}
+
+// deliverChat delivers a chat message sent in the session
+func (session *GameSession) deliverChat(channel ChatChannel, sender *Network, text string, recipients ...*Network) {
+	Chat(session.chat, channel, sender, text, recipients, slog.String("session_id", session.ID))
+}
+
+func (n *Network) mute(name string) {
+	n.muteMutex.Lock()
+	defer n.muteMutex.Unlock()
+
+	if n.mutes == nil {
+		n.mutes = make(map[string]struct{})
+	}
+
+	n.mutes[strings.ToLower(name)] = struct{}{}
+}
+
+func (n *Network) unmute(name string) {
+	n.muteMutex.Lock()
+	defer n.muteMutex.Unlock()
+
+	delete(n.mutes, strings.ToLower(name))
+}
+
+func (n *Network) muted(name string) bool {
+	n.muteMutex.Lock()
+	defer n.muteMutex.Unlock()
+
+	_, ok := n.mutes[strings.ToLower(name)]
+
+	return ok
+}
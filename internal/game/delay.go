@@ -0,0 +1,47 @@
+package game
+
+// stateBuffer is a ring buffer delaying the game states streamed to spectators by a number of ticks
+type stateBuffer struct {
+	states []GameState
+	next   int
+	full   bool
+}
+
+func newStateBuffer(delayTicks int) *stateBuffer {
+	return &stateBuffer{
+		states: make([]GameState, delayTicks),
+	}
+}
+
+// Push stores the state of the current tick and returns the state of the delayed tick
+//
+// Nothing is returned until the buffer is filled, i.e. during the first ticks of the session.
+func (b *stateBuffer) Push(state GameState) (GameState, bool) {
+	if len(b.states) == 0 {
+		return state, true
+	}
+
+	delayed, ok := b.states[b.next], b.full
+
+	b.states[b.next] = state
+	b.next = (b.next + 1) % len(b.states)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	return delayed, ok
+}
+
+// Drain empties the buffer, returning the states not streamed yet from the oldest to the newest
+func (b *stateBuffer) Drain() []GameState {
+	var states []GameState
+	if b.full {
+		states = append(states, b.states[b.next:]...)
+	}
+	states = append(states, b.states[:b.next]...)
+
+	b.next = 0
+	b.full = false
+
+	return states
+}
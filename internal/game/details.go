@@ -0,0 +1,102 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+// SessionDetails is a full description of a game session intended for the server administration
+type SessionDetails struct {
+	ID             string             `json:"id"`
+	Mode           Mode               `json:"mode"`
+	Seed           uint64             `json:"seed,string"`
+	Level          string             `json:"level"`
+	Tick           uint64             `json:"tick"`
+	StartTime      time.Time          `json:"start_time"`
+	Uptime         int64              `json:"uptime_ms"`
+	SpectatorDelay int64              `json:"spectator_delay_ms"`
+	Players        []PlayerDetails    `json:"players"`
+	Spectators     []SpectatorDetails `json:"spectators"`
+}
+
+type PlayerDetails struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	AccountID string        `json:"account_id,omitempty"`
+	Side      geometry.Side `json:"side"`
+	Score     int8          `json:"score"`
+	Ping      int64         `json:"ping"`
+	// Conceded and Eliminated are only set in the arena mode
+	Conceded   int8 `json:"conceded,omitempty"`
+	Eliminated bool `json:"eliminated,omitempty"`
+}
+
+type SpectatorDetails struct {
+	ID          string      `json:"id"`
+	Ping        int64       `json:"ping"`
+	JoinTime    time.Time   `json:"join_time"`
+	Perspective Perspective `json:"perspective,omitempty"`
+	Rate        int         `json:"rate,omitempty"`
+}
+
+// Details returns a snapshot of the session that is safe to be taken outside the game loop
+func (session *GameSession) Details() SessionDetails {
+	session.mutex.Lock()
+	details := SessionDetails{
+		ID:             session.ID,
+		Mode:           session.Mode,
+		Seed:           session.Seed,
+		Level:          session.level.String(),
+		Tick:           session.tick,
+		StartTime:      session.startTime,
+		Uptime:         session.clock.Now().Sub(session.startTime).Milliseconds(),
+		SpectatorDelay: session.spectatorDelay.Milliseconds(),
+		Players:        session.playersDetails(),
+	}
+	session.mutex.Unlock()
+
+	session.spectatorMutex.Lock()
+	details.Spectators = make([]SpectatorDetails, 0, len(session.spectators))
+	for _, spectator := range session.spectators {
+		details.Spectators = append(details.Spectators, SpectatorDetails{
+			ID:          spectator.ID,
+			Ping:        spectator.Latency.Milliseconds(),
+			JoinTime:    spectator.JoinTime,
+			Perspective: spectator.options.Perspective,
+			Rate:        spectator.options.Rate,
+		})
+	}
+	session.spectatorMutex.Unlock()
+
+	return details
+}
+
+func playerDetails(player *Player) PlayerDetails {
+	details := player.Network.Details()
+	details.Side = player.side
+	details.Score = player.score
+	details.Conceded = player.conceded
+	details.Eliminated = player.eliminated
+
+	return details
+}
+
+// Details describes the connected player, which has no side or score outside a session
+func (n *Network) Details() PlayerDetails {
+	return PlayerDetails{
+		ID:        n.ID,
+		Name:      n.PlayerName,
+		AccountID: n.AccountID,
+		Ping:      n.Latency.Milliseconds(),
+	}
+}
+
+func (session *GameSession) playersDetails() []PlayerDetails {
+	details := make([]PlayerDetails, 0, len(session.Players))
+	for _, player := range session.Players {
+		details = append(details, playerDetails(player))
+	}
+
+	return details
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+// Emote is a quick reaction a player can send during a match
+type Emote string
+
+const (
+	EmoteGoodLuck Emote = "good_luck"
+	EmoteNiceShot Emote = "nice_shot"
+	EmoteWow      Emote = "wow"
+	EmoteOops     Emote = "oops"
+	EmoteThanks   Emote = "thanks"
+	EmoteGG       Emote = "gg"
+)
+
+// Emotes is the set of emotes accepted by the server
+var Emotes = []Emote{EmoteGoodLuck, EmoteNiceShot, EmoteWow, EmoteOops, EmoteThanks, EmoteGG}
+
+var (
+	ErrUnknownEmote     = errors.New("unknown emote")
+	ErrEmoteRateLimited = errors.New("sending emotes too fast")
+)
+
+// Valid reports whether the emote is one of the accepted emotes
+func (e Emote) Valid() bool {
+	for _, emote := range Emotes {
+		if e == emote {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EmoteMessage is sent to the players and spectators of a session when a player sends an emote
+//
+// The Tick is the game loop update the emote was sent at, and the Side is the side of
+// the sender, so clients can show the emote next to its paddle.
+type EmoteMessage struct {
+	Emote EmoteEntry `json:"emote"`
+}
+
+type EmoteEntry struct {
+	ID   Emote         `json:"id"`
+	From string        `json:"from"`
+	Side geometry.Side `json:"side"`
+	Tick uint64        `json:"tick"`
+	Time time.Time     `json:"time"`
+}
+
+// EmoteRejectedMessage is sent to a player whose emote was refused, with the reason
+type EmoteRejectedMessage struct {
+	EmoteRejected string `json:"emote_rejected"`
+}
+
+// handleEmote validates an emote sent by a player and delivers it to every player
+//
+// Spectators receive the emote along with the delayed state of its tick, and players
+// that muted the sender doesn't receive it.
+func (session *GameSession) handleEmote(player *Player, emote Emote) {
+	err := ErrUnknownEmote
+	if emote.Valid() {
+		err = nil
+		if !session.emotes.Allow(player.ID) {
+			err = ErrEmoteRateLimited
+		}
+	}
+
+	if err != nil {
+		slog.Warn("Emote refused", slog.String("session_id", session.ID), slog.String("from", player.PlayerName), slog.Any("emote", emote), slog.Any("reason", err))
+		player.Send(EmoteRejectedMessage{EmoteRejected: err.Error()})
+		return
+	}
+
+	session.mutex.Lock()
+	tick := session.tick
+	session.mutex.Unlock()
+
+	message := EmoteMessage{Emote: EmoteEntry{
+		ID:   emote,
+		From: player.PlayerName,
+		Side: player.side,
+		Tick: tick,
+		Time: session.clock.Now(),
+	}}
+
+	for _, recipient := range session.Players {
+		if recipient != player && recipient.muted(player.PlayerName) {
+			continue
+		}
+
+		recipient.Send(message)
+	}
+
+	session.spectatorMutex.Lock()
+	defer session.spectatorMutex.Unlock()
+
+	if !session.spectatorsClosed {
+		session.spectatorEmotes = append(session.spectatorEmotes, message)
+	}
+}
+
+// sendEmotesToSpectators sends the emotes sent up to the tick of the streamed state, it
+// must be called with the spectators locked
+func (session *GameSession) sendEmotesToSpectators(tick uint64) {
+	sent := 0
+	for _, message := range session.spectatorEmotes {
+		if message.Emote.Tick > tick {
+			break
+		}
+
+		for _, spectator := range session.spectators {
+			spectator.Send(message)
+		}
+
+		sent++
+	}
+
+	session.spectatorEmotes = session.spectatorEmotes[sent:]
+}
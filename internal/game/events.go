@@ -0,0 +1,34 @@
+package game
+
+// PlayerEvent is the payload of the events about a single player
+type PlayerEvent struct {
+	Player PlayerDetails `json:"player"`
+}
+
+// QueueEvent is the payload of the event sent when a player joins the match queue
+type QueueEvent struct {
+	Player    PlayerDetails `json:"player"`
+	QueueSize int           `json:"queue_size"`
+}
+
+// QueueSizeEvent is the payload of the event sent when the number of players waiting in the match queue changes
+type QueueSizeEvent struct {
+	QueueSize int `json:"queue_size"`
+}
+
+// MatchEvent is the payload of the events sent when players are matched and their match starts
+type MatchEvent struct {
+	Players []PlayerDetails `json:"players"`
+}
+
+// GoalEvent is the payload of the event sent when a player scores
+//
+// The Conceder is the first player of the side the goal was scored against. The Scorer
+// is missing for arena goals conceded before any other player hit the ball. The players
+// details include the scores after the goal, and the Ball is the ID of the ball that scored.
+type GoalEvent struct {
+	Ball     int             `json:"ball"`
+	Scorer   *PlayerDetails  `json:"scorer,omitempty"`
+	Conceder PlayerDetails   `json:"conceder"`
+	Players  []PlayerDetails `json:"players"`
+}
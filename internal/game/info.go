@@ -10,9 +10,21 @@ var ErrPlayerInfoRequired = errors.New("invalid player info")
 // GameInfo represents the information sent by the player when connecting to the server
 //
 // It contains information necessary to identify the player and set the basis for the
-// physics simulation.
+// physics simulation. The AccountID is optional, and it's only sent by clients that
+// authenticate their players, allowing account bans and the tournament delay of the
+// accounts configured as competing in tournaments.
+//
+// The Mode is the game mode the player queues for, the classic mode by default. In team
+// modes, players queuing together send the same Party code, and are matched on the same side.
+// Power-ups are only spawned in the sessions whose players all ask for PowerUps. The
+// Layout is the name of the field layout the player queues for, the plain field by default.
 type GameInfo struct {
 	PlayerName       string `json:"player_name"`
+	AccountID        string `json:"account_id,omitempty"`
+	Mode             Mode   `json:"mode,omitempty"`
+	Party            string `json:"party,omitempty"`
+	PowerUps         bool   `json:"power_ups,omitempty"`
+	Layout           string `json:"layout,omitempty"`
 	Level            int    `json:"level"`
 	ScreenWidth      int    `json:"screen_width"`
 	ScreenHeight     int    `json:"screen_height"`
@@ -28,6 +40,15 @@ func (p GameInfo) Validate() error {
 	return nil
 }
 
+// GameMode returns the mode the player queues for
+func (p GameInfo) GameMode() Mode {
+	if p.Mode == "" {
+		return ModeClassic
+	}
+
+	return p.Mode
+}
+
 func PlayerInfoFromMsg(msg []byte) (GameInfo, error) {
 	var info GameInfo
 	if err := json.Unmarshal(msg, &info); err != nil {
@@ -7,44 +7,105 @@ import (
 // PlayerInput stores the player's input
 //
 // It's supposed to be received from the client only when there is
-// an effective action from the player (up or down)
+// an effective action from the player (up, down or serve)
 type PlayerInput struct {
-	Up   bool `json:"up"`
-	Down bool `json:"down"`
+	Up    bool `json:"up"`
+	Down  bool `json:"down"`
+	Serve bool `json:"serve,omitempty"`
 }
 
-func (player *Player) StartInputReader() {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				slog.Error("Recovered from panic", slog.Any("error", r))
-			}
+// ClientMessage is any message sent by a client after joining
+//
+// Besides the inputs, clients may send chat messages and emotes, and mute or unmute
+// other clients by name, hiding their chat messages and emotes.
+type ClientMessage struct {
+	PlayerInput
+	Chat   string `json:"chat,omitempty"`
+	Emote  Emote  `json:"emote,omitempty"`
+	Mute   string `json:"mute,omitempty"`
+	Unmute string `json:"unmute,omitempty"`
+}
+
+// MessageHandler handles the messages of a client, depending on where the client is:
+// the match queue, a session or a session's audience
+type MessageHandler func(message ClientMessage)
+
+// SetHandler replaces the handler of the client's messages
+func (n *Network) SetHandler(handler MessageHandler) {
+	n.handlerMutex.Lock()
+	defer n.handlerMutex.Unlock()
+
+	n.handler = handler
+}
+
+// ReadMessages reads the client's messages until its connection is terminated
+//
+// Mutes are handled by the connection itself, and every other message is passed to
+// the current handler. The connection is terminated once reading fails.
+func (n *Network) ReadMessages() {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Recovered from panic", slog.Any("error", r))
+		}
 
-			slog.Error("Player input reader stopped", slog.Any("player", player))
-			player.Terminate()
-		}()
+		slog.Info("Client message reader stopped", slog.String("id", n.ID), slog.String("name", n.PlayerName))
+		n.Terminate()
+	}()
 
-		for {
+	for {
+		var message ClientMessage
+		if err := n.Conn.ReadJSON(&message); err != nil {
 			select {
-			case <-player.Network.Ctx.Done():
-				return
+			case <-n.Ctx.Done():
 			default:
-				var input PlayerInput
-				if err := player.Network.Conn.ReadJSON(&input); err != nil {
-					slog.Error("Error reading player input", slog.Any("error", err))
-					player.Terminate()
-
-					return
-				}
+				slog.Error("Error reading client message", slog.Any("error", err), slog.String("id", n.ID))
+			}
 
-				if !input.Up && !input.Down {
-					continue
-				}
+			return
+		}
 
-				slog.Info("Received input", slog.Any("input", input))
+		switch {
+		case message.Mute != "":
+			n.mute(message.Mute)
+		case message.Unmute != "":
+			n.unmute(message.Unmute)
+		default:
+			n.handlerMutex.Lock()
+			handler := n.handler
+			n.handlerMutex.Unlock()
 
-				player.inputQueue <- input
+			if handler != nil {
+				handler(message)
 			}
 		}
-	}()
+	}
+}
+
+// handleMessage queues the player's inputs and delivers its chat messages and emotes
+func (session *GameSession) handleMessage(player *Player, message ClientMessage) {
+	if message.Chat != "" {
+		recipients := make([]*Network, 0, len(session.Players))
+		for _, recipient := range session.Players {
+			recipients = append(recipients, recipient.Network)
+		}
+
+		session.deliverChat(ChannelMatch, player.Network, message.Chat, recipients...)
+		return
+	}
+
+	if message.Emote != "" {
+		session.handleEmote(player, message.Emote)
+		return
+	}
+
+	if !message.Up && !message.Down && !message.Serve {
+		return
+	}
+
+	slog.Info("Received input", slog.Any("input", message.PlayerInput))
+
+	select {
+	case player.inputQueue <- message.PlayerInput:
+	case <-player.Network.Ctx.Done():
+	}
 }
@@ -1,28 +1,37 @@
 package game
 
-import "sync"
+import (
+	"errors"
+	"sync"
+)
 
-var sessionManager = NewSessionManager()
+var (
+	ErrSessionFull = errors.New("session spectator limit reached")
+	ErrServerFull  = errors.New("server spectator limit reached")
+	ErrSessionOver = errors.New("session ended")
+	ErrNameTaken   = errors.New("name taken by a player of the session")
+)
 
 // SessionManager stores all active game sessions
 //
-// This struct is supposed to be a singleton
+// Each server owns its own SessionManager, which is handed to the sessions
+// so they can remove themselves once finished. It also counts the spectators
+// watching any of its sessions, up to the given limit, which is disabled when
+// not positive.
 type SessionManager struct {
-	Sessions map[string]*GameSession
+	Sessions      map[string]*GameSession
+	maxSpectators int
+	spectators    int
 	sync.Mutex
 }
 
-func NewSessionManager() *SessionManager {
+func NewSessionManager(maxSpectators int) *SessionManager {
 	return &SessionManager{
-		Sessions: make(map[string]*GameSession),
+		Sessions:      make(map[string]*GameSession),
+		maxSpectators: maxSpectators,
 	}
 }
 
-// GetSessionManager returns the singleton instance of the SessionManager
-func GetSessionManager() *SessionManager {
-	return sessionManager
-}
-
 func (sm *SessionManager) AddSession(id string, session *GameSession) {
 	sm.Lock()
 	defer sm.Unlock()
@@ -54,3 +63,24 @@ func (sm *SessionManager) GetSessions() []*GameSession {
 	}
 	return sessions
 }
+
+// acquireSpectator reserves a spectator slot, which must be released once the spectator leaves
+func (sm *SessionManager) acquireSpectator() bool {
+	sm.Lock()
+	defer sm.Unlock()
+
+	if sm.maxSpectators > 0 && sm.spectators >= sm.maxSpectators {
+		return false
+	}
+
+	sm.spectators++
+
+	return true
+}
+
+func (sm *SessionManager) releaseSpectators(count int) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	sm.spectators = max(sm.spectators-count, 0)
+}
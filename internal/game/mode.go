@@ -0,0 +1,60 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// Mode is the game mode a player queues for
+type Mode string
+
+const (
+	// ModeClassic is the one against one match
+	ModeClassic Mode = "classic"
+	// ModeDoubles is the two against two match, each paddle restricted to its half of the side
+	ModeDoubles Mode = "doubles"
+	// ModeDoublesOpen is the two against two match, the paddles of a side moving freely and overlapping
+	ModeDoublesOpen Mode = "doubles_open"
+	// ModeArena is the four players free-for-all on a square field, each player defending a wall
+	ModeArena Mode = "arena"
+	// ModeMultiball is the one against one match with several balls in play at once
+	ModeMultiball Mode = "multiball"
+)
+
+var ErrInvalidMode = errors.New("unknown game mode")
+
+// Validate checks the mode is known, the empty mode being the classic mode
+func (m Mode) Validate() error {
+	switch m {
+	case "", ModeClassic, ModeDoubles, ModeDoublesOpen, ModeArena, ModeMultiball:
+		return nil
+	default:
+		return ErrInvalidMode
+	}
+}
+
+// TeamSize is the number of players on each side of the field
+func (m Mode) TeamSize() int {
+	switch m {
+	case ModeDoubles, ModeDoublesOpen:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Sides returns the sides of the field defended in the mode, in the order they are assigned to the players
+func (m Mode) Sides() []geometry.Side {
+	if m == ModeArena {
+		return []geometry.Side{geometry.Left, geometry.Right, physics.Top, physics.Bottom}
+	}
+
+	return []geometry.Side{geometry.Left, geometry.Right}
+}
+
+// lanes reports whether the paddles of a side are restricted to their own part of the side
+func (m Mode) lanes() bool {
+	return m == ModeDoubles
+}
@@ -6,11 +6,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
 // Network stores a player's websocket related information
+//
+// The ID identifies a connection for the purposes of administration, e.g.
+// kicking a player or spectator from a session.
 type Network struct {
+	ID           string             `json:"id"`
 	Conn         *websocket.Conn    `json:"-"`
 	mutex        sync.Mutex         `json:"-"`
 	closed       bool               `json:"-"`
@@ -19,6 +24,12 @@ type Network struct {
 	LastPingTime time.Time          `json:"-"`
 	Ctx          context.Context    `json:"-"`
 	Cancel       context.CancelFunc `json:"-"`
+	handler      MessageHandler
+	handlerMutex sync.Mutex
+	mutes        map[string]struct{}
+	muteMutex    sync.Mutex
+	// IP is the address of the client, empty when unknown
+	IP string `json:"-"`
 	GameInfo
 }
 
@@ -26,6 +37,7 @@ func NewNetwork(conn *websocket.Conn, info GameInfo) *Network {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	player := &Network{
+		ID:       uuid.NewString(),
 		Conn:     conn,
 		Latency:  0,
 		JoinTime: time.Now(),
@@ -50,6 +62,20 @@ func (n *Network) Send(data any) error {
 	return nil
 }
 
+// Close sends a close message with the given reason to the client and then terminates the connection
+func (n *Network) Close(reason string) {
+	n.mutex.Lock()
+	if n.Conn != nil && !n.closed {
+		err := n.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason), time.Now().Add(time.Second))
+		if err != nil {
+			slog.Error("Error writing close message", slog.Any("error", err), slog.String("reason", reason))
+		}
+	}
+	n.mutex.Unlock()
+
+	n.Terminate()
+}
+
 // Terminate is responsible for closing the player's connection and canceling the connection's context
 func (n *Network) Terminate() {
 	n.mutex.Lock()
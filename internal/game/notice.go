@@ -0,0 +1,8 @@
+package game
+
+// NoticeMessage is a server-wide announcement sent to every connected client
+//
+// It can be received at any moment, interleaved with the game state updates.
+type NoticeMessage struct {
+	Notice string `json:"notice"`
+}
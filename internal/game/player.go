@@ -1,87 +1,128 @@
 package game
 
 import (
-	"log/slog"
-	"time"
-
 	"github.com/gandarez/pong-multiplayer-go/pkg/engine/player"
 	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
-	"github.com/gorilla/websocket"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/physics"
 )
 
-const defaultSpeed = 4
-
 // Player unifies all multiplayer concerns about a player in the game
 //
-// The physics concerns are handled by the basePlayer, which shares the local
-// update logic with the client's local player processing.
+// The physics concerns are handled by the basePlayer, the paddle placed on the
+// session's field, which shares the local update logic with the client's local
+// player processing.
 //
 // The inputQueue streamlines the input processing, allowing the game loop to
 // process the player inputs in a controlled manner.
+//
+// The lane is the vertical part of the side the paddle is restricted to, when sharing
+// the side with teammates. A zero lane lets the paddle move along the whole side.
+//
+// In the arena mode, players count the goals they conceded, and they are eliminated
+// once they run out of lives. The served flag is set when the player pressed serve
+// among the inputs of the current update.
 type Player struct {
 	*Network
-	basePlayer player.Player
+	basePlayer *physics.Paddle
 	side       geometry.Side
 	score      int8
+	conceded   int8
+	eliminated bool
+	served     bool
 	inputQueue chan PlayerInput
+	lane       lane
 }
 
-func NewPlayer(network *Network, side geometry.Side) *Player {
-	basePlayer := player.NewLocal(network.PlayerName, side, float64(network.ScreenWidth), float64(network.ScreenHeight), float64(network.FieldBorderWidth))
+// lane is a vertical range of the field, from its top to its bottom
+type lane struct {
+	top    float64
+	bottom float64
+}
 
+// NewPlayer creates the player defending the given side, whose paddle is placed by the session
+func NewPlayer(network *Network, side geometry.Side, cfg config.Game) *Player {
 	player := &Player{
-		basePlayer: basePlayer,
 		Network:    network,
 		side:       side,
 		score:      0,
-		inputQueue: make(chan PlayerInput, 100),
+		inputQueue: make(chan PlayerInput, cfg.InputQueueSize),
 	}
 
 	return player
 }
 
+// Won notifies the player about the victory and closes the connection
 func (p *Player) Won() {
-	p.Network.mutex.Lock()
-	defer p.Network.mutex.Unlock()
-
-	if err := p.Network.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "You won!"), time.Now().Add(time.Second)); err != nil {
-		slog.Error("Error writing to player", slog.Any("error", err))
-	}
-
-	p.Network.Terminate()
+	p.Network.Close("You won!")
 }
 
+// Lost notifies the player about the defeat and closes the connection
 func (p *Player) Lost() {
-	p.Network.mutex.Lock()
-	defer p.Network.mutex.Unlock()
-
-	if err := p.Network.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "You lost!"), time.Now().Add(time.Second)); err != nil {
-		slog.Error("Error writing to player", slog.Any("error", err))
-	}
-
-	p.Network.Terminate()
+	p.Network.Close("You lost!")
 }
 
-func (p *Player) ProcessInputs() {
+// pendingInputs takes the inputs queued since the last update
+func (p *Player) pendingInputs() []PlayerInput {
+	var inputs []PlayerInput
 	for {
 		select {
 		case input := <-p.inputQueue:
-			p.basePlayer.Update(player.Input{
-				Up:   input.Up,
-				Down: input.Down,
-			})
+			inputs = append(inputs, input)
 		default:
-			return
+			return inputs
 		}
 	}
 }
 
-func (p *Player) MoveUp() {
-	p.basePlayer.SetPosition(p.basePlayer.Position().Y - defaultSpeed)
+// applyInputs moves the paddle with the inputs of the update, in order
+func (p *Player) applyInputs(inputs []PlayerInput) {
+	p.served = false
+
+	for _, input := range inputs {
+		p.served = p.served || input.Serve
+		p.basePlayer.Update(player.Input{
+			Up:   input.Up,
+			Down: input.Down,
+		})
+		p.keepInLane()
+	}
+}
+
+// place puts the player's paddle on the field, centered on its side, moving by speed on each input
+func (p *Player) place(field *physics.Field, speed float64) {
+	p.basePlayer = physics.NewPaddle(p.PlayerName, p.side, field, speed)
+}
+
+// restrict keeps the paddle within the lane, moving it to the lane's center
+func (p *Player) restrict(l lane) {
+	p.lane = l
+	p.basePlayer.SetPosition(l.top + (l.bottom-l.top-p.basePlayer.BouncerHeight())/2)
+}
+
+// scale resizes the paddle to the given factor of its regular length, within its lane
+func (p *Player) scale(factor float64) {
+	p.basePlayer.Scale(factor)
+	p.keepInLane()
+}
+
+func (p *Player) keepInLane() {
+	if p.lane == (lane{}) {
+		return
+	}
+
+	y := min(max(p.basePlayer.Position().Y, p.lane.top), p.lane.bottom-p.basePlayer.BouncerHeight())
+	p.basePlayer.SetPosition(y)
+}
+
+// Side returns the side of the field defended by the player
+func (p *Player) Side() geometry.Side {
+	return p.side
 }
 
-func (p *Player) MoveDown() {
-	p.basePlayer.SetPosition(p.basePlayer.Position().Y + defaultSpeed)
+// Score returns the player's current score
+func (p *Player) Score() int8 {
+	return p.score
 }
 
 func (p *Player) Terminate() {
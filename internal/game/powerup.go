@@ -0,0 +1,300 @@
+package game
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// PowerUp is a kind of power-up spawned on the field during a match
+type PowerUp string
+
+const (
+	// PowerUpGrow grows the paddle of the collecting player
+	PowerUpGrow PowerUp = "grow"
+	// PowerUpShrink shrinks the paddles of the collecting player's opponents
+	PowerUpShrink PowerUp = "shrink"
+	// PowerUpSpeedUp speeds up the ball that collected it
+	PowerUpSpeedUp PowerUp = "speed_up"
+	// PowerUpMultiball puts an extra ball in play
+	PowerUpMultiball PowerUp = "multiball"
+	// PowerUpShield closes the goal of the collecting player's side with a wall
+	PowerUpShield PowerUp = "shield"
+)
+
+// PowerUps is the set of power-ups spawned by the server
+var PowerUps = []PowerUp{PowerUpGrow, PowerUpShrink, PowerUpSpeedUp, PowerUpMultiball, PowerUpShield}
+
+const (
+	powerUpSize     = 20
+	powerUpLifetime = 15 * time.Second
+	maxPowerUps     = 3
+	maxExtraBalls   = 2
+	growFactor      = 1.5
+	shrinkFactor    = 0.6
+	speedUpBoost    = 2
+)
+
+// PowerUpState is a power-up waiting on the field to be collected, from its spawn tick to its expiry tick
+type PowerUpState struct {
+	ID       int             `json:"id"`
+	Kind     PowerUp         `json:"kind"`
+	Position geometry.Vector `json:"position"`
+	Size     float64         `json:"size"`
+	Spawned  uint64          `json:"spawned"`
+	Expires  uint64          `json:"expires"`
+}
+
+// PickupState is a power-up collected by a player during the tick of the state
+type PickupState struct {
+	ID   int           `json:"id"`
+	Kind PowerUp       `json:"kind"`
+	Name string        `json:"name"`
+	Side geometry.Side `json:"side"`
+}
+
+// EffectState is a power-up effect on a player, active until the given tick
+type EffectState struct {
+	Kind  PowerUp       `json:"kind"`
+	Name  string        `json:"name"`
+	Side  geometry.Side `json:"side"`
+	Until uint64        `json:"until"`
+}
+
+// effect is an active power-up effect on a player's paddle, or on its side for shields
+type effect struct {
+	kind   PowerUp
+	player *Player
+	until  uint64
+}
+
+// powerUpSpawner spawns the power-ups of a session and keeps track of their effects
+//
+// The spawns follow a schedule drawn from the spawner's own random source, so the same
+// seed spawns the same power-ups at the same ticks and positions, whatever happens in the
+// match. A spawn is skipped when the field already holds the maximum number of power-ups.
+type powerUpSpawner struct {
+	rand     *rand.Rand
+	interval uint64
+	lifetime uint64
+	duration uint64
+	next     uint64
+	lastID   int
+	spawned  []PowerUpState
+	effects  []effect
+	// pickups are the power-ups collected during the current tick
+	pickups []PickupState
+}
+
+// newPowerUpSpawner creates the spawner of a session, with the intervals converted to ticks
+func newPowerUpSpawner(seed uint64, cfg config.Game) *powerUpSpawner {
+	tickInterval := cfg.TickInterval()
+
+	spawner := &powerUpSpawner{
+		rand:     rand.New(rand.NewPCG(seed, seed)),
+		interval: uint64(max(cfg.PowerUpInterval/tickInterval, 1)),
+		lifetime: uint64(powerUpLifetime / tickInterval),
+		duration: uint64(max(cfg.PowerUpDuration/tickInterval, 1)),
+	}
+	spawner.next = spawner.delay()
+
+	return spawner
+}
+
+// delay returns the ticks until the next spawn, between half and one and a half intervals
+func (s *powerUpSpawner) delay() uint64 {
+	return s.interval/2 + s.rand.Uint64N(s.interval+1)
+}
+
+// spawn draws the next scheduled power-up, placed within the middle of the field
+func (s *powerUpSpawner) spawn(field *physics.Field, tick uint64) PowerUpState {
+	s.lastID++
+
+	return PowerUpState{
+		ID:   s.lastID,
+		Kind: PowerUps[s.rand.IntN(len(PowerUps))],
+		Position: geometry.Vector{
+			X: field.Width/4 + s.rand.Float64()*(field.Width/2-powerUpSize),
+			Y: field.Height/4 + s.rand.Float64()*(field.Height/2-powerUpSize),
+		},
+		Size:    powerUpSize,
+		Spawned: tick,
+		Expires: tick + s.lifetime,
+	}
+}
+
+// updatePowerUps expires the power-ups and effects that timed out and spawns the scheduled power-ups
+func (session *GameSession) updatePowerUps() {
+	spawner := session.powerUps
+	if spawner == nil {
+		return
+	}
+
+	spawner.pickups = nil
+
+	spawned := spawner.spawned[:0]
+	for _, powerUp := range spawner.spawned {
+		if powerUp.Expires > session.tick {
+			spawned = append(spawned, powerUp)
+		}
+	}
+	spawner.spawned = spawned
+
+	var expired []*Player
+	effects := spawner.effects[:0]
+	for _, effect := range spawner.effects {
+		if effect.until > session.tick {
+			effects = append(effects, effect)
+			continue
+		}
+
+		expired = append(expired, effect.player)
+	}
+	spawner.effects = effects
+
+	for _, player := range expired {
+		session.applyEffects(player)
+	}
+
+	if session.tick >= spawner.next {
+		powerUp := spawner.spawn(session.field, session.tick)
+		if len(spawner.spawned) < maxPowerUps {
+			spawner.spawned = append(spawner.spawned, powerUp)
+		}
+
+		spawner.next += spawner.delay()
+	}
+}
+
+// collectPowerUps gives the power-ups the ball passes through to the player that hit it last
+//
+// Balls that weren't hit since they were served pass through the power-ups.
+func (session *GameSession) collectPowerUps(b *ball) {
+	spawner := session.powerUps
+	if spawner == nil || b.lastHit == nil || b.lastHit.eliminated {
+		return
+	}
+
+	bounds := b.Bounds()
+
+	var collected []PowerUpState
+	spawned := spawner.spawned[:0]
+	for _, powerUp := range spawner.spawned {
+		area := geometry.Rect{X: powerUp.Position.X, Y: powerUp.Position.Y, Width: powerUp.Size, Height: powerUp.Size}
+		if bounds.Intersects(area) {
+			collected = append(collected, powerUp)
+			continue
+		}
+
+		spawned = append(spawned, powerUp)
+	}
+	spawner.spawned = spawned
+
+	for _, powerUp := range collected {
+		session.applyPowerUp(powerUp, b)
+	}
+}
+
+// applyPowerUp applies the effect of the power-up collected by the ball's last hitter
+func (session *GameSession) applyPowerUp(powerUp PowerUpState, b *ball) {
+	player := b.lastHit
+
+	switch powerUp.Kind {
+	case PowerUpGrow, PowerUpShield:
+		session.addEffect(powerUp.Kind, player)
+	case PowerUpShrink:
+		for _, opponent := range session.opponents(player) {
+			if !opponent.eliminated {
+				session.addEffect(PowerUpShrink, opponent)
+			}
+		}
+	case PowerUpSpeedUp:
+		b.Boost(speedUpBoost)
+	case PowerUpMultiball:
+		if session.extraBalls() < maxExtraBalls {
+			session.addBall(true, session.tick)
+		}
+	}
+
+	session.powerUps.pickups = append(session.powerUps.pickups, PickupState{
+		ID:   powerUp.ID,
+		Kind: powerUp.Kind,
+		Name: player.PlayerName,
+		Side: player.side,
+	})
+
+	slog.Info("Power-up collected", slog.String("session_id", session.ID), slog.String("name", player.PlayerName), slog.Any("kind", powerUp.Kind))
+}
+
+// addEffect puts the effect on the player for the configured duration, extending the
+// same effect when already active
+func (session *GameSession) addEffect(kind PowerUp, player *Player) {
+	spawner := session.powerUps
+	until := session.tick + spawner.duration
+
+	found := false
+	for i, effect := range spawner.effects {
+		if effect.kind == kind && effect.player == player {
+			spawner.effects[i].until = until
+			found = true
+		}
+	}
+
+	if !found {
+		spawner.effects = append(spawner.effects, effect{kind: kind, player: player, until: until})
+	}
+
+	session.applyEffects(player)
+}
+
+// applyEffects sizes the player's paddle and shields its side according to the active effects
+//
+// A side stays shielded while any of its players has an active shield, and its goal is
+// only opened again while the side is still defended.
+func (session *GameSession) applyEffects(player *Player) {
+	factor := 1.0
+	shielded := false
+	for _, effect := range session.powerUps.effects {
+		switch {
+		case effect.player == player && effect.kind == PowerUpGrow:
+			factor *= growFactor
+		case effect.player == player && effect.kind == PowerUpShrink:
+			factor *= shrinkFactor
+		case effect.player.side == player.side && effect.kind == PowerUpShield:
+			shielded = true
+		}
+	}
+
+	player.scale(factor)
+
+	switch {
+	case shielded:
+		session.field.Close(player.side)
+	case !player.eliminated:
+		session.field.Open(player.side)
+	}
+}
+
+// powerUpsState returns the spawned power-ups, the pickups of the current tick and the active effects
+func (session *GameSession) powerUpsState() ([]PowerUpState, []PickupState, []EffectState) {
+	spawner := session.powerUps
+	if spawner == nil {
+		return nil, nil, nil
+	}
+
+	effects := make([]EffectState, 0, len(spawner.effects))
+	for _, effect := range spawner.effects {
+		effects = append(effects, EffectState{
+			Kind:  effect.kind,
+			Name:  effect.player.PlayerName,
+			Side:  effect.player.side,
+			Until: effect.until,
+		})
+	}
+
+	return append([]PowerUpState(nil), spawner.spawned...), spawner.pickups, effects
+}
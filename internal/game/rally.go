@@ -0,0 +1,167 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// PlayEventType is the kind of a play event
+type PlayEventType string
+
+const (
+	// PlayHit is a ball bouncing on a player's paddle
+	PlayHit PlayEventType = "hit"
+	// PlayWall is a ball bouncing on a wall of the field
+	PlayWall PlayEventType = "wall"
+	// PlayObstacle is a ball bouncing on an obstacle of the field layout
+	PlayObstacle PlayEventType = "obstacle"
+	// PlayGoal is a ball scoring a goal
+	PlayGoal PlayEventType = "goal"
+	// PlayLongestRally is a goal ending the longest rally of the match so far
+	PlayLongestRally PlayEventType = "longest_rally"
+)
+
+// PlayEvent is something that happened to a ball during the tick of the state
+//
+// The Ball is the ID of the ball. For hits, the Name and Side are the hitting player's and
+// the Speed is the ball speed after the bounce. For wall bounces, the Side is the wall.
+// For goals, the Name and Side are the scorer's, missing when nobody is credited, and the
+// Rally is the number of paddle hits since the ball was served. The Obstacle is the index
+// of the layout obstacle the ball bounced on.
+type PlayEvent struct {
+	Type     PlayEventType `json:"type"`
+	Ball     int           `json:"ball"`
+	Name     string        `json:"name,omitempty"`
+	Side     geometry.Side `json:"side,omitempty"`
+	Speed    float64       `json:"speed,omitempty"`
+	Obstacle int           `json:"obstacle,omitempty"`
+	Rally    int           `json:"rally,omitempty"`
+}
+
+// MatchStats are the statistics of a match, computed from its play events
+//
+// A rally counts the paddle hits of a ball from its serve to its goal, and the rallies
+// still going on when the match ended aren't counted. The TopSpeed is in pixels per tick.
+type MatchStats struct {
+	Hits         int         `json:"hits"`
+	Rallies      int         `json:"rallies"`
+	AverageRally float64     `json:"average_rally"`
+	LongestRally int         `json:"longest_rally"`
+	TopSpeed     float64     `json:"top_speed"`
+	Sides        []SideStats `json:"sides"`
+}
+
+// SideStats are the statistics of a side of the field
+//
+// The time in possession adds up the time of every ball in play last hit by the side.
+type SideStats struct {
+	Side         geometry.Side `json:"side"`
+	Hits         int           `json:"hits"`
+	Goals        int           `json:"goals"`
+	PossessionMs int64         `json:"possession_ms"`
+}
+
+// rallies records the play events of a session, and the statistics computed from them
+type rallies struct {
+	// events are the play events of the current tick
+	events     []PlayEvent
+	hits       int
+	rallies    int
+	rallyHits  int
+	longest    int
+	topSpeed   float64
+	sideHits   map[geometry.Side]int
+	goals      map[geometry.Side]int
+	possession map[geometry.Side]uint64
+}
+
+func newRallies() *rallies {
+	return &rallies{
+		sideHits:   make(map[geometry.Side]int),
+		goals:      make(map[geometry.Side]int),
+		possession: make(map[geometry.Side]uint64),
+	}
+}
+
+// recordCollision records the bounces of the ball during its update
+func (session *GameSession) recordCollision(b *ball, collision physics.Collision) {
+	r := session.rallies
+
+	if collision.Wall != geometry.Undefined {
+		r.events = append(r.events, PlayEvent{Type: PlayWall, Ball: b.id, Side: collision.Wall})
+	}
+
+	if collision.Obstacle >= 0 {
+		r.events = append(r.events, PlayEvent{Type: PlayObstacle, Ball: b.id, Obstacle: collision.Obstacle})
+	}
+
+	if collision.Paddle >= 0 {
+		b.rally++
+		r.hits++
+		r.sideHits[b.lastHit.side]++
+		r.events = append(r.events, PlayEvent{
+			Type:  PlayHit,
+			Ball:  b.id,
+			Name:  b.lastHit.PlayerName,
+			Side:  b.lastHit.side,
+			Speed: b.Speed(),
+		})
+	}
+
+	r.topSpeed = max(r.topSpeed, b.Speed())
+
+	if b.lastHit != nil {
+		r.possession[b.lastHit.side]++
+	}
+}
+
+// recordGoal records the goal of the ball, ending its rally
+func (session *GameSession) recordGoal(b *ball, scorer *Player) {
+	r := session.rallies
+
+	event := PlayEvent{Type: PlayGoal, Ball: b.id, Rally: b.rally}
+	if scorer != nil {
+		event.Name, event.Side = scorer.PlayerName, scorer.side
+		r.goals[scorer.side]++
+	}
+	r.events = append(r.events, event)
+
+	r.rallies++
+	r.rallyHits += b.rally
+
+	if b.rally > r.longest {
+		r.longest = b.rally
+		r.events = append(r.events, PlayEvent{Type: PlayLongestRally, Ball: b.id, Rally: b.rally})
+	}
+
+	b.rally = 0
+}
+
+// stats returns the statistics of the match so far
+func (session *GameSession) stats() MatchStats {
+	r := session.rallies
+
+	stats := MatchStats{
+		Hits:         r.hits,
+		Rallies:      r.rallies,
+		LongestRally: r.longest,
+		TopSpeed:     r.topSpeed,
+	}
+
+	if r.rallies > 0 {
+		stats.AverageRally = float64(r.rallyHits) / float64(r.rallies)
+	}
+
+	for _, side := range session.Mode.Sides() {
+		stats.Sides = append(stats.Sides, SideStats{
+			Side:         side,
+			Hits:         r.sideHits[side],
+			Goals:        r.goals[side],
+			PossessionMs: (time.Duration(r.possession[side]) * session.tickInterval).Milliseconds(),
+		})
+	}
+
+	return stats
+}
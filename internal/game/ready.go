@@ -1,14 +1,25 @@
 package game
 
-import "github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+import (
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
 
 // ReadyMessage is the first message sent to the players after connecting and finding a match
 //
 // It conveys information about the opponent player name and which side each player is allocated.
+// In team modes, the OpponentName is the first of the Opponents, and the Teammates share the
+// player's side. PowerUps is set when power-ups spawn during the match, and the Layout is
+// the field layout of the match, missing on the plain field.
 type ReadyMessage struct {
-	Ready        bool          `json:"ready"`
-	Name         string        `json:"name"`
-	OpponentName string        `json:"opponent_name"`
-	Side         geometry.Side `json:"side"`
-	OpponentSide geometry.Side `json:"opponent_side"`
+	Ready        bool            `json:"ready"`
+	Mode         Mode            `json:"mode"`
+	Name         string          `json:"name"`
+	OpponentName string          `json:"opponent_name"`
+	Side         geometry.Side   `json:"side"`
+	OpponentSide geometry.Side   `json:"opponent_side"`
+	Opponents    []string        `json:"opponents"`
+	Teammates    []string        `json:"teammates,omitempty"`
+	PowerUps     bool            `json:"power_ups,omitempty"`
+	Layout       *physics.Layout `json:"layout,omitempty"`
 }
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+// EndReason describes why a game session ended
+type EndReason string
+
+const (
+	EndByScore         EndReason = "score"
+	EndByDisconnection EndReason = "disconnection"
+	EndByServer        EndReason = "server"
+)
+
+// MatchResult is the final outcome of a game session
+//
+// The WinnerSide is the side of the winning players, and the WinnerID is the network ID
+// of the first of them. Both are empty when the session ended without a winner. A player
+// that disconnects forfeits the match for its whole side. The Seed is the random seed of
+// the session, the inputs of the players aren't recorded. The Stats are computed from the
+// play events of the match.
+type MatchResult struct {
+	SessionID  string          `json:"session_id"`
+	Mode       Mode            `json:"mode"`
+	Seed       uint64          `json:"seed,string"`
+	StartTime  time.Time       `json:"start_time"`
+	EndTime    time.Time       `json:"end_time"`
+	Players    []PlayerDetails `json:"players"`
+	WinnerSide geometry.Side   `json:"winner_side,omitempty"`
+	WinnerID   string          `json:"winner_id,omitempty"`
+	Reason     EndReason       `json:"reason"`
+	Stats      MatchStats      `json:"stats"`
+}
+
+// Ended reports whether the match is over, for sessions stepped outside of the game loop
+func (session *GameSession) Ended() bool {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.gameEnded()
+}
+
+// Result returns the result of the match, won by the leading side once it's over, and
+// ended by the server without a winner otherwise
+func (session *GameSession) Result() MatchResult {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if !session.gameEnded() {
+		return session.result(geometry.Undefined, EndByServer)
+	}
+
+	return session.result(session.leader(), EndByScore)
+}
+
+func (session *GameSession) result(winnerSide geometry.Side, reason EndReason) MatchResult {
+	result := MatchResult{
+		SessionID:  session.ID,
+		Mode:       session.Mode,
+		Seed:       session.Seed,
+		StartTime:  session.startTime,
+		EndTime:    session.clock.Now(),
+		Players:    session.playersDetails(),
+		WinnerSide: winnerSide,
+		Reason:     reason,
+		Stats:      session.stats(),
+	}
+
+	if winners := session.team(winnerSide); len(winners) > 0 {
+		result.WinnerID = winners[0].Network.ID
+	}
+
+	return result
+}
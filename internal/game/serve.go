@@ -0,0 +1,166 @@
+package game
+
+import (
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/config"
+)
+
+// ServeRule decides the side the ball is served toward after a goal
+type ServeRule string
+
+const (
+	// ServeToConceder serves the ball toward the side that conceded the goal
+	ServeToConceder ServeRule = "conceder"
+	// ServeAlternate serves the ball toward every side in turn
+	ServeAlternate ServeRule = "alternate"
+	// ServeRandom serves the ball toward a random side
+	ServeRandom ServeRule = "random"
+)
+
+// serveRules are the serve rules of a session, with the durations converted to ticks
+type serveRules struct {
+	rule    ServeRule
+	delay   uint64
+	button  bool
+	timeout uint64
+}
+
+func newServeRules(cfg config.Game) serveRules {
+	tickInterval := cfg.TickInterval()
+
+	return serveRules{
+		rule:    ServeRule(cfg.ServeRule),
+		delay:   uint64(cfg.ServeDelay / tickInterval),
+		button:  cfg.ServeByButton,
+		timeout: uint64(cfg.ServeTimeout / tickInterval),
+	}
+}
+
+// serve is the serve of a ball waiting in the center of the field
+//
+// The ball is launched toward the side on the launch tick, or once a player of the side
+// presses serve when the button is required, the ball being launched anyway on the
+// deadline tick.
+type serve struct {
+	toward   geometry.Side
+	launch   uint64
+	button   bool
+	deadline uint64
+	launched bool
+}
+
+// ServeState is the serve of a ball waiting in the center of the field
+//
+// The ball is served toward the side on the Launch tick, or, when the Button is required,
+// once a player of the side presses serve, or on the Deadline tick.
+type ServeState struct {
+	Toward   geometry.Side `json:"toward"`
+	Launch   uint64        `json:"launch"`
+	Button   bool          `json:"button,omitempty"`
+	Deadline uint64        `json:"deadline,omitempty"`
+}
+
+// serveBall puts the ball in the center of the field, waiting to be launched toward the side
+func (session *GameSession) serveBall(b *ball, toward geometry.Side, launch uint64, button bool) {
+	b.Serve(toward)
+	b.serve = serve{
+		toward:   toward,
+		launch:   launch,
+		button:   button,
+		deadline: launch + session.serve.timeout,
+	}
+
+	session.lastServe = toward
+}
+
+// launched reports whether the ball is in play, launching it when its serve is due
+func (session *GameSession) launched(b *ball) bool {
+	if b.serve.launched {
+		return true
+	}
+
+	if session.tick < b.serve.launch {
+		return false
+	}
+
+	if b.serve.button && session.tick < b.serve.deadline && !session.pressedServe(b.serve.toward) {
+		return false
+	}
+
+	b.serve.launched = true
+
+	return true
+}
+
+// pressedServe reports whether a player of the side pressed serve since the last update
+func (session *GameSession) pressedServe(side geometry.Side) bool {
+	for _, player := range session.team(side) {
+		if player.served {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveSide returns the side of the next serve, after a goal conceded on the given side
+//
+// Only the sides still defended are served toward. The first serve of a match, and
+// the serves toward a side that was just eliminated, go toward a random side.
+func (session *GameSession) serveSide(conceded geometry.Side) geometry.Side {
+	var sides []geometry.Side
+	for _, side := range session.Mode.Sides() {
+		if session.defended(side) {
+			sides = append(sides, side)
+		}
+	}
+
+	if len(sides) == 0 {
+		return geometry.Left
+	}
+
+	switch session.serve.rule {
+	case ServeToConceder:
+		if conceded != geometry.Undefined && session.defended(conceded) {
+			return conceded
+		}
+	case ServeAlternate:
+		for i, side := range sides {
+			if side == session.lastServe {
+				return sides[(i+1)%len(sides)]
+			}
+		}
+	}
+
+	return sides[session.rand.IntN(len(sides))]
+}
+
+// defended reports whether any player of the side is still in the match
+func (session *GameSession) defended(side geometry.Side) bool {
+	for _, player := range session.team(side) {
+		if !player.eliminated {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveState returns the serve of the ball, or nil when it's in play
+func (b *ball) serveState() *ServeState {
+	if b.serve.launched {
+		return nil
+	}
+
+	state := &ServeState{
+		Toward: b.serve.toward,
+		Launch: b.serve.launch,
+		Button: b.serve.button,
+	}
+
+	if b.serve.button {
+		state.Deadline = b.serve.deadline
+	}
+
+	return state
+}
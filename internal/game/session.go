@@ -2,243 +2,731 @@ package game
 
 import (
 	"log/slog"
+	"math/rand/v2"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/gandarez/pong-multiplayer-go/pkg/engine/ball"
 	"github.com/gandarez/pong-multiplayer-go/pkg/engine/level"
+	"github.com/gandarez/pong-multiplayer-go/pkg/engine/player"
 	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
 	"github.com/google/uuid"
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/chat"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/events"
+	"github.com/reneepc/pongo-server/internal/physics"
 )
 
-// GameSession represents a multiplayer session between two players
+// GameSession represents a multiplayer session between the sides of the field
 //
-// It contains physics related objects: the ball and the players, the
-// game server clock (ticker), and selected game level.
+// It contains physics related objects: the field, the ball and the players, the
+// game server clock (ticker), and selected game level. In the classic mode each
+// side has a single player, and in the team modes each side has a team of players
+// sharing the side's score. In the arena mode, four players defend the four walls
+// of a square field until a single player survives.
+//
+// The session's balls are always in play, a single one except in the multiball mode, and
+// they're served again after scoring. Extra balls are put in play by power-ups, and
+// they're removed once they score.
 //
 // The ID identifies a session for the purposes of listing, streaming, and
 // replaying a given session.
+//
+// Every random draw of the session, from the serves to the bounces and the power-ups,
+// comes from its Seed, and the physics only move on Step, so the same seed and inputs
+// always give the same game states. The clock paces the game loop and dates the goals.
 type GameSession struct {
-	ID      string
-	Player1 *Player
-	Player2 *Player
-	ball    ball.Ball
-	level   level.Level
-	ticker  *time.Ticker
+	ID   string
+	Mode Mode
+	Seed uint64
+	// Players are the players of every side, ordered as the mode's sides
+	Players []*Player
+	field   *physics.Field
+	layout  *physics.Layout
+	balls   []*ball
+	// baseBalls is the number of balls the mode plays with, besides the power-ups extra balls
+	baseBalls int
+	// lastBallID is the ID of the last ball put in play
+	lastBallID int
+	rand       *rand.Rand
+	// powerUps spawns the session's power-ups, nil when they are disabled
+	powerUps *powerUpSpawner
+	serve    serveRules
+	// rallies records the play events and the match statistics
+	rallies *rallies
+	// lastServe is the side the last ball was served toward
+	lastServe    geometry.Side
+	lives        int8
+	level        level.Level
+	clock        Clock
+	ticker       Ticker
+	tickInterval time.Duration
+	startTime    time.Time
+	tick         uint64
+	goals        []Goal
+	mutex        sync.Mutex
+	manager      *SessionManager
+	events       *events.Bus
+	chat         *chat.Moderator
+	emotes       *access.RateLimiter
+
+	// Administration
+	forceEnd chan geometry.Side
+	done     chan struct{}
 
 	// Spectate
-	spectators     []*Network
-	spectatorMutex sync.Mutex
+	spectators      []*spectator
+	maxSpectators   int
+	spectatorDelay  time.Duration
+	spectatorStates *stateBuffer
+	spectatorMutex  sync.Mutex
+	// lastSpectatorState is the last state streamed to the spectators
+	lastSpectatorState *GameState
+	// spectatorEmotes are the emotes waiting for the delayed state of their tick
+	spectatorEmotes []EmoteMessage
+	// spectatorsClosed is set once the session stopped streaming to spectators
+	spectatorsClosed bool
 }
 
-func NewGameSession(player1 *Player, player2 *Player) *GameSession {
-	return &GameSession{
-		ID:      uuid.NewString(),
-		Player1: player1,
-		Player2: player2,
-		ball:    ball.NewLocal(float64(player1.ScreenWidth), float64(player1.ScreenHeight), level.Medium),
-		level:   level.Medium,
+// NewGameSession creates a session of the given mode and field layout between the players,
+// which removes itself from the manager when finished
+//
+// The players must be ordered as the mode's sides, and the field is the screen of the first
+// player, the largest square fitting in it for the arena mode. A nil layout is the plain
+// field. In the modes with lanes, the paddles of each side are restricted to equal parts of
+// the side, in the players order from the top.
+//
+// Power-ups are spawned when enabled by the configuration and requested by every player.
+//
+// The session events: the start, goals, disconnections and the end of the match, are
+// published to the given event bus, and the chat messages of its players and
+// spectators are checked by the given moderator.
+//
+// Spectators watch the game with the configured spectator delay, or with the tournament
+// delay when any of the players' accounts is configured as competing in a tournament.
+//
+// The session runs on the system clock with a random seed at the medium level, unless
+// chosen by the options.
+func NewGameSession(mode Mode, layout *physics.Layout, players []*Player, cfg config.Game, manager *SessionManager, moderator *chat.Moderator, bus *events.Bus, options ...SessionOption) *GameSession {
+	settings := sessionSettings{seed: rand.Uint64(), clock: SystemClock{}, level: level.Medium}
+	for _, option := range options {
+		option(&settings)
+	}
+
+	tournament := false
+	powerUps := cfg.PowerUpInterval > 0
+	for _, player := range players {
+		if cfg.TournamentAccount(player.AccountID) {
+			tournament = true
+		}
+
+		if !player.PowerUps {
+			powerUps = false
+		}
+	}
+
+	tickInterval := cfg.TickInterval()
+	spectatorDelay := cfg.SpectatorDelayFor(tournament)
+
+	width, height := float64(players[0].ScreenWidth), float64(players[0].ScreenHeight)
+	if mode == ModeArena {
+		width = min(width, height)
+		height = width
+	}
+
+	field := physics.NewField(width, height, float64(players[0].FieldBorderWidth), mode.Sides()...)
+	if layout != nil {
+		field.SetLayout(layout)
+	}
+
+	random := rand.New(rand.NewPCG(settings.seed, settings.seed))
+
+	session := &GameSession{
+		ID:              uuid.NewString(),
+		Mode:            mode,
+		Seed:            settings.seed,
+		Players:         players,
+		field:           field,
+		layout:          layout,
+		rand:            random,
+		serve:           newServeRules(cfg),
+		rallies:         newRallies(),
+		lives:           int8(cfg.ArenaLives),
+		level:           settings.level,
+		clock:           settings.clock,
+		tickInterval:    tickInterval,
+		startTime:       settings.clock.Now(),
+		manager:         manager,
+		events:          bus,
+		chat:            moderator,
+		emotes:          access.NewRateLimiter(cfg.EmoteRate, cfg.EmoteBurst),
+		forceEnd:        make(chan geometry.Side),
+		done:            make(chan struct{}),
+		spectatorDelay:  spectatorDelay,
+		maxSpectators:   cfg.MaxSpectatorsPerSession,
+		spectatorStates: newStateBuffer(int((spectatorDelay + tickInterval - 1) / tickInterval)),
+	}
+
+	for _, player := range players {
+		player.place(field, cfg.PaddleSpeed)
+	}
+
+	if mode.lanes() {
+		session.assignLanes()
+	}
+
+	if powerUps {
+		session.powerUps = newPowerUpSpawner(random.Uint64(), cfg)
+	}
+
+	session.baseBalls = 1
+	if mode == ModeMultiball {
+		session.baseBalls = cfg.MultiballBalls
+	}
+
+	// The balls of the multiball mode are launched one after the other
+	for i := range session.baseBalls {
+		session.addBall(false, uint64(i)*uint64(time.Second/tickInterval))
+	}
+
+	return session
+}
+
+// SessionOption customizes a session created by NewGameSession
+type SessionOption func(*sessionSettings)
+
+type sessionSettings struct {
+	seed  uint64
+	clock Clock
+	level level.Level
+}
+
+// WithSeed draws every random number of the session from the given seed
+func WithSeed(seed uint64) SessionOption {
+	return func(s *sessionSettings) {
+		s.seed = seed
+	}
+}
+
+// WithLevel sets the level of the session, deciding how fast the balls speed up
+func WithLevel(lvl level.Level) SessionOption {
+	return func(s *sessionSettings) {
+		s.level = lvl
+	}
+}
+
+// WithClock runs the session on the given clock
+func WithClock(clock Clock) SessionOption {
+	return func(s *sessionSettings) {
+		s.clock = clock
+	}
+}
+
+// assignLanes splits the height of the field between the players of each side
+func (session *GameSession) assignLanes() {
+	top := session.field.Border
+	bottom := session.field.Height - session.field.Border
+
+	for _, side := range []geometry.Side{geometry.Left, geometry.Right} {
+		team := session.team(side)
+		height := (bottom - top) / float64(len(team))
+
+		for i, player := range team {
+			player.restrict(lane{
+				top:    top + float64(i)*height,
+				bottom: top + float64(i+1)*height,
+			})
+		}
 	}
 }
 
 // Start begins the game loop
 //
-// The game is processed in a fixed time step loop, given by the server clock (ticker).
-// The game loop process the player inputs, updates the game physics, broadcasts the
-// game state to the players.
+// The game is processed in a fixed time step loop, given by the session clock (ticker).
+// On every tick, the game loop takes the player inputs, steps the game physics and
+// broadcasts the game state to the players.
 //
-// It also handles players disconnections, scores, and game ending.
+// It also handles players disconnections, scores, game ending, and matches
+// forcefully ended by the server administration.
 func (session *GameSession) Start() {
-	session.ticker = time.NewTicker(time.Second / 60)
+	session.ticker = session.clock.NewTicker(session.tickInterval)
 	defer session.ticker.Stop()
+	defer close(session.done)
+
+	disconnected := make(chan *Player, len(session.Players))
+	for _, player := range session.Players {
+		player.SetHandler(func(message ClientMessage) {
+			session.handleMessage(player, message)
+		})
+
+		go func() {
+			<-player.Network.Ctx.Done()
+			disconnected <- player
+		}()
+	}
 
 	session.ready()
 
 	for {
 		select {
-		case <-session.Player1.Network.Ctx.Done():
-			session.handleDisconnection(session.Player1)
+		case player := <-disconnected:
+			if session.handleDisconnection(player) {
+				return
+			}
+		case winnerSide := <-session.forceEnd:
+			slog.Warn("Game forcefully ended", slog.String("session_id", session.ID), slog.Any("winner_side", winnerSide))
+			if len(session.team(winnerSide)) == 0 {
+				winnerSide = geometry.Undefined
+			}
+			session.endGame(winnerSide, EndByServer)
 			return
-		case <-session.Player2.Network.Ctx.Done():
-			session.handleDisconnection(session.Player2)
-		case <-session.ticker.C:
-			session.update()
+		case <-session.ticker.C():
+			gameState := session.Step(session.pendingInputs())
 
-			session.broadcastGameState()
+			session.broadcastGameState(gameState)
 
-			session.broadcastToSpectators(session.currentGameState())
+			session.streamToSpectators(gameState)
 
 			if session.gameEnded() {
 				session.ticker.Stop()
-				session.endGame()
+				session.endGame(session.leader(), EndByScore)
+				return
 			}
 		}
 	}
 }
 
-func (session *GameSession) ready() {
-	go session.Player1.Network.Send(ReadyMessage{
-		Ready:        true,
-		Name:         session.Player1.PlayerName,
-		OpponentName: session.Player2.PlayerName,
-		Side:         session.Player1.side,
-		OpponentSide: session.Player2.side,
-	})
-	go session.Player2.Network.Send(ReadyMessage{
-		Ready:        true,
-		Name:         session.Player2.PlayerName,
-		OpponentName: session.Player1.PlayerName,
-		Side:         session.Player2.side,
-		OpponentSide: session.Player1.side,
-	})
+// ForceEnd ends the game from outside the game loop with the player on the given side as the winner
+//
+// Passing geometry.Undefined ends the game without a winner. It has no effect
+// if the game loop has already stopped, and it returns once the game loop stops.
+func (session *GameSession) ForceEnd(winnerSide geometry.Side) {
+	select {
+	case session.forceEnd <- winnerSide:
+		<-session.done
+	case <-session.done:
+	}
+}
+
+// KickPlayer closes the connection of the session's player with the given network ID
+//
+// The game loop handles the kick as a regular disconnection, notifying the other players.
+func (session *GameSession) KickPlayer(id string) bool {
+	for _, player := range session.Players {
+		if player.Network.ID == id {
+			slog.Warn("Kicking player", slog.String("session_id", session.ID), slog.String("name", player.PlayerName))
+			player.Network.Close("Kicked by the server")
+			return true
+		}
+	}
 
-	slog.Info("Game started", slog.String("session_id", session.ID), slog.Any("player1", session.Player1), slog.Any("player2", session.Player2))
+	return false
 }
 
-func (session *GameSession) update() {
-	session.Player1.ProcessInputs()
-	session.Player2.ProcessInputs()
+func (session *GameSession) ready() {
+	for _, player := range session.Players {
+		opponents := session.opponents(player)
+
+		message := ReadyMessage{
+			Ready:        true,
+			Mode:         session.Mode,
+			Name:         player.PlayerName,
+			OpponentName: opponents[0].PlayerName,
+			Side:         player.side,
+			OpponentSide: opponents[0].side,
+			Opponents:    playerNames(opponents),
+			PowerUps:     session.powerUps != nil,
+			Layout:       session.layout,
+		}
 
-	session.ball.Update(session.Player1.basePlayer.Bounds(), session.Player2.basePlayer.Bounds())
+		for _, teammate := range session.team(player.side) {
+			if teammate != player {
+				message.Teammates = append(message.Teammates, teammate.PlayerName)
+			}
+		}
 
-	if scored, goalSide := session.ball.CheckGoal(); scored {
-		session.handleScore(goalSide)
+		go player.Network.Send(message)
 	}
+
+	slog.Info("Game started", slog.String("session_id", session.ID), slog.Any("mode", session.Mode), slog.Uint64("seed", session.Seed), slog.Any("players", playerNames(session.Players)))
+
+	session.events.Publish(events.New(events.MatchStarted, session.ID, MatchEvent{Players: session.playersDetails()}))
 }
 
-func (session *GameSession) broadcastGameState() {
-	player1 := PlayerState{
-		Name:      session.Player1.PlayerName,
-		PositionY: session.Player1.basePlayer.Position().Y,
-		Score:     session.Player1.score,
-		Side:      session.Player1.side,
-		Ping:      session.Player1.Network.Latency.Milliseconds(),
-		Winner:    session.winner(session.Player1),
+// pendingInputs takes the inputs each player sent since the last step, in the players order
+func (session *GameSession) pendingInputs() [][]PlayerInput {
+	inputs := make([][]PlayerInput, len(session.Players))
+	for i, player := range session.Players {
+		inputs[i] = player.pendingInputs()
 	}
 
-	player2 := PlayerState{
-		Name:      session.Player2.PlayerName,
-		PositionY: session.Player2.basePlayer.Position().Y,
-		Score:     session.Player2.score,
-		Side:      session.Player2.side,
-		Ping:      session.Player2.Network.Latency.Milliseconds(),
-		Winner:    session.winner(session.Player2),
+	return inputs
+}
+
+// Step advances the game by one tick and returns its state
+//
+// The inputs are the ones of each player, in the players order, applied in order before
+// the balls move. The inputs of eliminated players are ignored. Step doesn't depend on the
+// time, so stepping through the same inputs from the same seed gives the same states.
+func (session *GameSession) Step(inputs [][]PlayerInput) GameState {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.tick++
+
+	session.rallies.events = nil
+
+	session.updatePowerUps()
+
+	session.field.Update()
+
+	paddles := make([]player.Player, 0, len(session.Players))
+	players := make([]*Player, 0, len(session.Players))
+	for i, player := range session.Players {
+		if player.eliminated {
+			continue
+		}
+
+		if i < len(inputs) {
+			player.applyInputs(inputs[i])
+		}
+
+		paddles = append(paddles, player.basePlayer)
+		players = append(players, player)
 	}
 
-	err := session.Player1.Network.Send(GameState{
-		Ball:     ballState(session.ball),
-		Current:  player1,
-		Opponent: player2,
-	})
-	if err != nil {
-		slog.Error("Error sending game state to player 1", slog.Any("error", err), slog.Any("player", session.Player1))
+	// Balls put in play during the update start moving on the next one
+	for _, b := range slices.Clone(session.balls) {
+		if !session.launched(b) {
+			continue
+		}
+
+		collision := b.Update(paddles)
+
+		if collision.Paddle >= 0 {
+			b.lastHit = players[collision.Paddle]
+		}
+
+		session.recordCollision(b, collision)
+
+		session.collectPowerUps(b)
+
+		if collision.Goal != geometry.Undefined {
+			session.handleScore(b, collision.Goal)
+		}
 	}
 
-	err = session.Player2.Network.Send(GameState{
-		Ball:     ballState(session.ball),
-		Current:  player2,
-		Opponent: player1,
-	})
-	if err != nil {
-		slog.Error("Error sending game state to player 2", slog.Any("error", err), slog.Any("player", session.Player2))
+	return session.currentGameState()
+}
+
+// broadcastGameState sends the game state to every player, framed with the player as the current one
+func (session *GameSession) broadcastGameState(gameState GameState) {
+	for _, player := range session.Players {
+		playerState := gameState
+		playerState.Current = session.playerState(player)
+		playerState.Opponent = session.playerState(session.opponents(player)[0])
+
+		if err := player.Network.Send(playerState); err != nil {
+			slog.Error("Error sending game state to player", slog.Any("error", err), slog.Any("player", player))
+		}
 	}
 }
 
-func (session *GameSession) handleDisconnection(disconnectedPlayer *Player) {
+// handleDisconnection ends the game with the disconnected player's side forfeiting the match,
+// reporting whether the game ended
+//
+// In the arena mode, the disconnected player is eliminated instead, and the game only ends
+// when a single player survives.
+func (session *GameSession) handleDisconnection(disconnectedPlayer *Player) bool {
 	disconnectedPlayer.Terminate()
 
-	var remainingPlayer *Player
-	if disconnectedPlayer == session.Player1 {
-		remainingPlayer = session.Player2
+	slog.Warn("Player disconnected", slog.String("name", disconnectedPlayer.Network.GameInfo.PlayerName))
+
+	session.events.Publish(events.New(events.PlayerDisconnected, session.ID, PlayerEvent{Player: playerDetails(disconnectedPlayer)}))
+
+	if session.Mode == ModeArena {
+		session.mutex.Lock()
+		if !disconnectedPlayer.eliminated {
+			session.eliminate(disconnectedPlayer)
+		}
+		session.mutex.Unlock()
+
+		if !session.gameEnded() {
+			return false
+		}
+
+		session.endGame(session.leader(), EndByDisconnection)
+		return true
+	}
+
+	for _, player := range session.Players {
+		switch {
+		case player == disconnectedPlayer:
+		case player.side == disconnectedPlayer.side:
+			player.Network.Close("Teammate disconnected")
+		default:
+			player.Network.opponentDisconnect()
+			player.Terminate()
+		}
+	}
+
+	go session.finishSpectators()
+
+	session.manager.RemoveSession(session.ID)
+
+	session.events.Publish(events.New(events.MatchEnded, session.ID, session.result(otherSide(disconnectedPlayer.side), EndByDisconnection)))
+
+	return true
+}
+
+// handleScore credits the goal the ball scored on the goal side
+//
+// In the classic and team modes, the goal is credited to every player of the other
+// side. In the arena mode, the conceding player loses a life, being eliminated when
+// out of lives, and the goal is credited to the player that hit the ball last.
+func (session *GameSession) handleScore(b *ball, goalSide geometry.Side) {
+	conceder := session.team(goalSide)[0]
+	scorer := session.scorer(conceder, b)
+
+	if session.Mode == ModeArena {
+		conceder.conceded++
+		if scorer != nil {
+			scorer.score++
+		}
 	} else {
-		remainingPlayer = session.Player1
+		for _, player := range session.opponents(conceder) {
+			player.score++
+		}
 	}
 
-	slog.Warn("Player disconnected", slog.String("name", disconnectedPlayer.Network.GameInfo.PlayerName))
+	goal := Goal{
+		Ball:    b.id,
+		Tick:    session.tick,
+		Time:    session.clock.Now(),
+		Against: goalSide,
+	}
+
+	event := GoalEvent{Ball: b.id, Conceder: playerDetails(conceder)}
 
-	remainingPlayer.Network.opponentDisconnect()
-	remainingPlayer.Terminate()
+	if scorer != nil {
+		goal.Name, goal.Side, goal.Score = scorer.PlayerName, scorer.side, scorer.score
 
-	for _, spectator := range session.spectators {
-		spectator.Terminate()
+		details := playerDetails(scorer)
+		event.Scorer = &details
 	}
 
-	sessionManager.RemoveSession(session.ID)
+	session.goals = append(session.goals, goal)
+
+	session.recordGoal(b, scorer)
+
+	if session.Mode == ModeArena && conceder.conceded >= session.lives {
+		session.eliminate(conceder)
+	}
+
+	session.resetBall(b, goalSide)
+
+	event.Players = session.playersDetails()
+	session.events.Publish(events.New(events.GoalScored, session.ID, event))
 }
 
-func (session *GameSession) handleScore(goalSide geometry.Side) {
-	if session.Player1.side == goalSide {
-		session.Player2.score++
-	} else {
-		session.Player1.score++
+// scorer returns the player credited with a goal conceded by the given player
+//
+// It's the player that hit the ball last, unless it's a teammate of the conceding player.
+// Otherwise, the goal is credited to the first opponent in the classic and team modes, and
+// to nobody in the arena mode.
+func (session *GameSession) scorer(conceder *Player, b *ball) *Player {
+	if b.lastHit != nil && b.lastHit.side != conceder.side {
+		return b.lastHit
 	}
 
-	session.resetBall(goalSide)
+	if session.Mode == ModeArena {
+		return nil
+	}
+
+	return session.opponents(conceder)[0]
+}
+
+// eliminate takes the player out of an arena match, turning its goal into a wall
+func (session *GameSession) eliminate(player *Player) {
+	player.eliminated = true
+	session.field.Close(player.side)
+
+	slog.Info("Player eliminated", slog.String("session_id", session.ID), slog.String("name", player.PlayerName))
+
+	session.events.Publish(events.New(events.PlayerEliminated, session.ID, PlayerEvent{Player: playerDetails(player)}))
+}
+
+// survivors returns the players that weren't eliminated
+func (session *GameSession) survivors() []*Player {
+	var survivors []*Player
+	for _, player := range session.Players {
+		if !player.eliminated {
+			survivors = append(survivors, player)
+		}
+	}
+
+	return survivors
 }
 
 func (session *GameSession) gameEnded() bool {
-	return session.winner(session.Player1) || session.winner(session.Player2)
+	if session.Mode == ModeArena {
+		return len(session.survivors()) <= 1
+	}
+
+	for _, player := range session.Players {
+		if session.winner(player) {
+			return true
+		}
+	}
+
+	return false
 }
 
-func (session *GameSession) endGame() {
-	if session.Player1.score == session.Player1.MaxScore {
-		session.Player1.Won()
-		session.Player2.Lost()
-	} else {
-		session.Player2.Won()
-		session.Player1.Lost()
+// endGame notifies the players about the result and closes the session
+//
+// An undefined winner side means the game was ended by the server without a winner.
+func (session *GameSession) endGame(winnerSide geometry.Side, reason EndReason) {
+	for _, player := range session.Players {
+		switch {
+		case winnerSide == geometry.Undefined:
+			player.Network.Close("Game ended by the server")
+		case player.side == winnerSide:
+			player.Won()
+		default:
+			player.Lost()
+		}
 	}
 
-	for _, spectator := range session.spectators {
-		spectator.Terminate()
+	go session.finishSpectators()
+
+	session.manager.RemoveSession(session.ID)
+
+	session.events.Publish(events.New(events.MatchEnded, session.ID, session.result(winnerSide, reason)))
+}
+
+// leader returns the side with the highest score, or geometry.Undefined on a draw
+//
+// In the arena mode, it's the side of the last survivor.
+func (session *GameSession) leader() geometry.Side {
+	if session.Mode == ModeArena {
+		if survivors := session.survivors(); len(survivors) == 1 {
+			return survivors[0].side
+		}
+
+		return geometry.Undefined
 	}
 
-	sessionManager.RemoveSession(session.ID)
+	left, right := session.team(geometry.Left)[0], session.team(geometry.Right)[0]
+
+	switch {
+	case left.score > right.score:
+		return geometry.Left
+	case right.score > left.score:
+		return geometry.Right
+	default:
+		return geometry.Undefined
+	}
 }
 
-func (session *GameSession) resetBall(scorer geometry.Side) {
-	if scorer == geometry.Left {
-		session.ball = session.ball.Reset()
-	} else {
-		session.ball = session.ball.Reset()
+// team returns the players defending the given side
+func (session *GameSession) team(side geometry.Side) []*Player {
+	var team []*Player
+	for _, player := range session.Players {
+		if player.side == side {
+			team = append(team, player)
+		}
 	}
+
+	return team
 }
 
+// opponents returns the players defending the other sides
+func (session *GameSession) opponents(player *Player) []*Player {
+	var opponents []*Player
+	for _, opponent := range session.Players {
+		if opponent.side != player.side {
+			opponents = append(opponents, opponent)
+		}
+	}
+
+	return opponents
+}
+
+// winner reports whether the player won, by reaching its max score or by surviving the arena
 func (session *GameSession) winner(player *Player) bool {
+	if session.Mode == ModeArena {
+		return !player.eliminated && len(session.survivors()) == 1
+	}
+
 	return player.score >= player.MaxScore
 }
 
+func (session *GameSession) playerState(player *Player) PlayerState {
+	return PlayerState{
+		Name:       player.PlayerName,
+		PositionY:  player.basePlayer.Position().Y,
+		Score:      player.score,
+		Conceded:   player.conceded,
+		Side:       player.side,
+		Ping:       player.Network.Latency.Milliseconds(),
+		Winner:     session.winner(player),
+		Eliminated: player.eliminated,
+	}
+}
+
+// currentGameState returns the state of the game, framed with the first left player as the current one
 func (session *GameSession) currentGameState() GameState {
-	player1State := PlayerState{
-		Name:      session.Player1.PlayerName,
-		PositionY: session.Player1.basePlayer.Position().Y,
-		Score:     session.Player1.score,
-		Side:      session.Player1.side,
-		Ping:      session.Player1.Network.Latency.Milliseconds(),
-		Winner:    session.winner(session.Player1),
+	gameState := GameState{
+		Tick:       session.tick,
+		Ball:       ballState(session.balls[0]),
+		Balls:      make([]BallState, 0, len(session.balls)),
+		Current:    session.playerState(session.Players[0]),
+		Opponent:   session.playerState(session.opponents(session.Players[0])[0]),
+		Paddles:    make([]PaddleState, 0, len(session.Players)),
+		Spectators: session.spectatorCount(),
+	}
+
+	for _, player := range session.Players {
+		gameState.Paddles = append(gameState.Paddles, PaddleState{
+			Name:       player.PlayerName,
+			Side:       player.side,
+			Position:   player.basePlayer.Position(),
+			Length:     player.basePlayer.BouncerHeight(),
+			Ping:       player.Network.Latency.Milliseconds(),
+			Eliminated: player.eliminated,
+		})
 	}
 
-	player2State := PlayerState{
-		Name:      session.Player2.PlayerName,
-		PositionY: session.Player2.basePlayer.Position().Y,
-		Score:     session.Player2.score,
-		Side:      session.Player2.side,
-		Ping:      session.Player2.Network.Latency.Milliseconds(),
-		Winner:    session.winner(session.Player2),
+	for _, b := range session.balls {
+		gameState.Balls = append(gameState.Balls, ballState(b))
 	}
 
-	return GameState{
-		Ball:     ballState(session.ball),
-		Current:  player1State,
-		Opponent: player2State,
+	for _, obstacle := range session.field.Obstacles {
+		gameState.Obstacles = append(gameState.Obstacles, obstacle.Bounds())
 	}
+
+	gameState.PowerUps, gameState.Pickups, gameState.Effects = session.powerUpsState()
+
+	gameState.Events = session.rallies.events
+	gameState.LongestRally = session.rallies.longest
+
+	return gameState
 }
 
-func (session *GameSession) broadcastToSpectators(gameState GameState) {
-	session.spectatorMutex.Lock()
-	defer session.spectatorMutex.Unlock()
+func otherSide(side geometry.Side) geometry.Side {
+	if side == geometry.Left {
+		return geometry.Right
+	}
 
-	for _, spectator := range session.spectators {
-		spectator.Send(gameState)
+	return geometry.Left
+}
+
+func playerNames(players []*Player) []string {
+	names := make([]string, 0, len(players))
+	for _, player := range players {
+		names = append(names, player.PlayerName)
 	}
+
+	return names
 }
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+// SpectatorSnapshot is the first message sent to a spectator after joining a session
+//
+// It introduces the session and catches the spectator up with the match: the State is
+// the last state streamed to the spectators, and the Goals are the goals scored until
+// then. Both follow the spectator delay, and the State is nil while the delayed stream
+// hasn't started yet.
+type SpectatorSnapshot struct {
+	Snapshot       bool          `json:"snapshot"`
+	SessionID      string        `json:"session_id"`
+	Level          string        `json:"level"`
+	Ruleset        Ruleset       `json:"ruleset"`
+	Players        []PlayerIntro `json:"players"`
+	StartTime      time.Time     `json:"start_time"`
+	SpectatorDelay int64         `json:"spectator_delay_ms"`
+	State          *GameState    `json:"state"`
+	Goals          []Goal        `json:"goals"`
+}
+
+// Ruleset describes the rules and field of a session
+type Ruleset struct {
+	Mode             Mode      `json:"mode"`
+	TickRate         int       `json:"tick_rate"`
+	ScreenWidth      int       `json:"screen_width"`
+	ScreenHeight     int       `json:"screen_height"`
+	FieldBorderWidth int       `json:"field_border_width"`
+	Layout           string    `json:"layout,omitempty"`
+	Balls            int       `json:"balls"`
+	PowerUps         bool      `json:"power_ups"`
+	ServeRule        ServeRule `json:"serve_rule"`
+	ServeDelay       int64     `json:"serve_delay_ms"`
+	ServeByButton    bool      `json:"serve_by_button"`
+}
+
+// PlayerIntro presents a session player, who wins by reaching the max score
+type PlayerIntro struct {
+	Name     string        `json:"name"`
+	Side     geometry.Side `json:"side"`
+	MaxScore int8          `json:"max_score"`
+}
+
+// Goal is an entry of the session's goal timeline
+//
+// The Score is the scorer's score after the goal, Against is the side the goal was
+// scored against, and Ball is the ID of the ball that scored. Arena goals conceded
+// before any other player hit the ball have no scorer.
+type Goal struct {
+	Ball    int           `json:"ball"`
+	Tick    uint64        `json:"tick"`
+	Time    time.Time     `json:"time"`
+	Name    string        `json:"name,omitempty"`
+	Side    geometry.Side `json:"side,omitempty"`
+	Score   int8          `json:"score"`
+	Against geometry.Side `json:"against"`
+}
+
+// ruleset returns the rules of the session, the field being the one of the first player
+func (session *GameSession) ruleset() Ruleset {
+	return Ruleset{
+		Mode:             session.Mode,
+		TickRate:         int(time.Second / session.tickInterval),
+		ScreenWidth:      int(session.field.Width),
+		ScreenHeight:     int(session.field.Height),
+		FieldBorderWidth: session.Players[0].FieldBorderWidth,
+		Layout:           session.layoutName(),
+		Balls:            session.baseBalls,
+		PowerUps:         session.powerUps != nil,
+		ServeRule:        session.serve.rule,
+		ServeDelay:       (time.Duration(session.serve.delay) * session.tickInterval).Milliseconds(),
+		ServeByButton:    session.serve.button,
+	}
+}
+
+// layoutName returns the name of the session's field layout, empty for the plain field
+func (session *GameSession) layoutName() string {
+	if session.layout == nil {
+		return ""
+	}
+
+	return session.layout.Name
+}
+
+// spectatorSnapshot builds the snapshot of the session up to the last streamed state
+//
+// The state is framed from the spectator's perspective, the goals are taken from the given
+// timeline, and it must be called with the spectators locked.
+func (session *GameSession) spectatorSnapshot(spectator *spectator, goals []Goal) SpectatorSnapshot {
+	snapshot := SpectatorSnapshot{
+		Snapshot:       true,
+		SessionID:      session.ID,
+		Level:          session.level.String(),
+		Ruleset:        session.ruleset(),
+		StartTime:      session.startTime,
+		SpectatorDelay: session.spectatorDelay.Milliseconds(),
+		Goals:          make([]Goal, 0, len(goals)),
+	}
+
+	if session.lastSpectatorState != nil {
+		state := spectator.frame(*session.lastSpectatorState)
+		snapshot.State = &state
+	}
+
+	for _, player := range session.Players {
+		snapshot.Players = append(snapshot.Players, PlayerIntro{
+			Name:     player.PlayerName,
+			Side:     player.side,
+			MaxScore: player.MaxScore,
+		})
+	}
+
+	// Goals not streamed yet would reveal the match ahead of the delay
+	if snapshot.State != nil {
+		for _, goal := range goals {
+			if goal.Tick <= snapshot.State.Tick {
+				snapshot.Goals = append(snapshot.Goals, goal)
+			}
+		}
+	}
+
+	return snapshot
+}
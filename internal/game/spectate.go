@@ -1,23 +1,265 @@
 package game
 
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+// Perspective is whose point of view a spectator follows
+type Perspective string
+
+const (
+	// PerspectiveNeutral follows no player, framing the left player as the current one
+	PerspectiveNeutral Perspective = "neutral"
+	PerspectiveLeft    Perspective = "left"
+	PerspectiveRight   Perspective = "right"
+)
+
+var ErrInvalidSpectateOptions = errors.New("invalid spectate options")
+
+// SpectateOptions are chosen by each spectator when joining a session
+//
+// The perspective decides which player is framed as the current player of the game
+// states, and the rate is how many game states per second the spectator receives,
+// up to the session tick rate. A zero rate receives every game state.
+type SpectateOptions struct {
+	Perspective Perspective `json:"perspective,omitempty"`
+	Rate        int         `json:"rate,omitempty"`
+}
+
+func (o SpectateOptions) Validate() error {
+	switch o.Perspective {
+	case "", PerspectiveNeutral, PerspectiveLeft, PerspectiveRight:
+	default:
+		return ErrInvalidSpectateOptions
+	}
+
+	if o.Rate < 0 {
+		return ErrInvalidSpectateOptions
+	}
+
+	return nil
+}
+
+// spectator is a spectator connection along with how it watches the session
+type spectator struct {
+	*Network
+	options SpectateOptions
+	// lastFrame is the downsampled frame of the last game state sent
+	lastFrame uint64
+	sent      bool
+	// skippedEvents are the play events of the game states skipped by the rate
+	skippedEvents []PlayEvent
+}
+
+// frame returns the game state as seen from the spectator's perspective
+func (s *spectator) frame(gameState GameState) GameState {
+	side := geometry.Left
+	if s.options.Perspective == PerspectiveRight {
+		side = geometry.Right
+	}
+
+	if gameState.Current.Side != side {
+		gameState.Current, gameState.Opponent = gameState.Opponent, gameState.Current
+	}
+
+	return gameState
+}
+
+// sendState sends the game state unless the spectator rate skips its tick
+//
+// The play events of the skipped game states are sent with the next game state.
+func (s *spectator) sendState(gameState GameState, tickRate int) {
+	if s.options.Rate > 0 && s.options.Rate < tickRate {
+		frame := gameState.Tick * uint64(s.options.Rate) / uint64(tickRate)
+		if s.sent && frame == s.lastFrame {
+			s.skippedEvents = append(s.skippedEvents, gameState.Events...)
+			return
+		}
+
+		s.lastFrame = frame
+	}
+
+	if len(s.skippedEvents) > 0 {
+		gameState.Events = append(s.skippedEvents, gameState.Events...)
+		s.skippedEvents = nil
+	}
+
+	s.sent = true
+	s.Send(s.frame(gameState))
+}
+
 // AddSpectator adds a spectator to a given session from which it will
 // receive buffered game updates
-func (session *GameSession) AddSpectator(spectator *Network) {
+//
+// The spectator first receives a snapshot of the session, catching it up with the
+// match, and then the delayed game states, framed and downsampled as chosen in the options.
+//
+// It fails with ErrSessionFull or ErrServerFull when the session or the server spectator
+// limits are reached, with ErrSessionOver once the session stopped streaming, and with
+// ErrNameTaken when the spectator's name is the name of one of the players.
+func (session *GameSession) AddSpectator(network *Network, options SpectateOptions) error {
+	session.mutex.Lock()
+	goals := make([]Goal, len(session.goals))
+	copy(goals, session.goals)
+	session.mutex.Unlock()
+
 	session.spectatorMutex.Lock()
 	defer session.spectatorMutex.Unlock()
 
+	if session.spectatorsClosed {
+		return ErrSessionOver
+	}
+
+	// Spectators can't chat under the name of a player
+	for _, player := range session.Players {
+		if network.PlayerName != "" && strings.EqualFold(network.PlayerName, player.PlayerName) {
+			return ErrNameTaken
+		}
+	}
+
+	if session.maxSpectators > 0 && len(session.spectators) >= session.maxSpectators {
+		return ErrSessionFull
+	}
+
+	if !session.manager.acquireSpectator() {
+		return ErrServerFull
+	}
+
+	spectator := &spectator{Network: network, options: options}
+
+	network.SetHandler(func(message ClientMessage) {
+		if message.Chat != "" {
+			session.deliverChat(ChannelSpectators, network, message.Chat, session.Spectators()...)
+		}
+	})
+
+	if err := spectator.Send(session.spectatorSnapshot(spectator, goals)); err != nil {
+		slog.Error("Error sending snapshot to spectator", slog.Any("error", err), slog.String("session_id", session.ID))
+	}
+
 	session.spectators = append(session.spectators, spectator)
+
+	return nil
 }
 
 // RemoveSpectator removes a spectator from a given session
-func (session *GameSession) RemoveSpectator(spectator *Network) {
+func (session *GameSession) RemoveSpectator(network *Network) {
 	session.spectatorMutex.Lock()
 	defer session.spectatorMutex.Unlock()
 
 	for i, s := range session.spectators {
-		if s == spectator {
+		if s.Network == network {
 			session.spectators = append(session.spectators[:i], session.spectators[i+1:]...)
+			session.manager.releaseSpectators(1)
 			break
 		}
 	}
 }
+
+// KickSpectator removes the spectator with the given network ID from the session and closes its connection
+func (session *GameSession) KickSpectator(id string) bool {
+	session.spectatorMutex.Lock()
+	defer session.spectatorMutex.Unlock()
+
+	for i, spectator := range session.spectators {
+		if spectator.ID == id {
+			session.spectators = append(session.spectators[:i], session.spectators[i+1:]...)
+			session.manager.releaseSpectators(1)
+			spectator.Close("Kicked by the server")
+			return true
+		}
+	}
+
+	return false
+}
+
+// Spectators returns a copy of the session's current spectators
+func (session *GameSession) Spectators() []*Network {
+	session.spectatorMutex.Lock()
+	defer session.spectatorMutex.Unlock()
+
+	spectators := make([]*Network, 0, len(session.spectators))
+	for _, spectator := range session.spectators {
+		spectators = append(spectators, spectator.Network)
+	}
+
+	return spectators
+}
+
+// streamToSpectators buffers the state of the current tick and sends the delayed state to the spectators
+func (session *GameSession) streamToSpectators(gameState GameState) {
+	session.spectatorMutex.Lock()
+	defer session.spectatorMutex.Unlock()
+
+	delayed, ok := session.spectatorStates.Push(gameState)
+	if !ok {
+		return
+	}
+
+	session.sendToSpectators(delayed)
+}
+
+// sendToSpectators sends the state to every spectator, it must be called with the spectators locked
+func (session *GameSession) sendToSpectators(gameState GameState) {
+	session.lastSpectatorState = &gameState
+
+	tickRate := session.ruleset().TickRate
+	for _, spectator := range session.spectators {
+		spectator.sendState(gameState, tickRate)
+	}
+
+	session.sendEmotesToSpectators(gameState.Tick)
+}
+
+// finishSpectators streams the states still delayed at the game loop pace once the game
+// ended, so spectators watch the game until its end, and then terminates their connections
+func (session *GameSession) finishSpectators() {
+	session.spectatorMutex.Lock()
+	states := session.spectatorStates.Drain()
+	watched := len(session.spectators) > 0
+	session.spectatorMutex.Unlock()
+
+	if !watched {
+		session.terminateSpectators()
+		return
+	}
+
+	ticker := session.clock.NewTicker(session.tickInterval)
+	defer ticker.Stop()
+
+	for _, gameState := range states {
+		<-ticker.C()
+
+		session.spectatorMutex.Lock()
+		session.sendToSpectators(gameState)
+		session.spectatorMutex.Unlock()
+	}
+
+	session.terminateSpectators()
+}
+
+func (session *GameSession) terminateSpectators() {
+	session.spectatorMutex.Lock()
+	defer session.spectatorMutex.Unlock()
+
+	for _, spectator := range session.spectators {
+		spectator.Terminate()
+	}
+
+	session.manager.releaseSpectators(len(session.spectators))
+	session.spectators = nil
+	session.spectatorEmotes = nil
+	session.spectatorsClosed = true
+}
+
+// spectatorCount returns the number of spectators currently watching the session
+func (session *GameSession) spectatorCount() int {
+	session.spectatorMutex.Lock()
+	defer session.spectatorMutex.Unlock()
+
+	return len(session.spectators)
+}
@@ -1,22 +1,49 @@
 package game
 
-import (
-	"github.com/gandarez/pong-multiplayer-go/pkg/engine/ball"
-	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
-)
+import "github.com/gandarez/pong-multiplayer-go/pkg/geometry"
 
 // GameState is a snapshot of the game physics at a given time.
 //
 // The game state is used to synchronize the game between the server and the clients.
 // At a constant rate, the server sends state updates to the clients in response to the client's inputs.
 // The clients use the state updates to render the game and predict the game physics.
+//
+// The Tick is the game loop update the state was taken at, and Spectators is the number
+// of spectators watching the session at that time.
+//
+// The Opponent is the first player of the other side, and the Paddles hold every player
+// of the session, so team modes are rendered from the paddles. The Ball is the first of
+// the Balls in play.
+//
+// In sessions with power-ups, the PowerUps are the power-ups waiting on the field, the
+// Pickups the power-ups collected on this tick, and the Effects the active effects. The
+// Obstacles are the rectangles of the layout obstacles, at their current positions.
+//
+// The Events are the paddle hits, bounces and goals of the tick, and the LongestRally is
+// the most paddle hits of a ball between its serve and a goal so far in the match.
 type GameState struct {
-	Ball     BallState   `json:"ball"`
-	Current  PlayerState `json:"current"`
-	Opponent PlayerState `json:"opponent"`
+	Tick      uint64          `json:"tick"`
+	Ball      BallState       `json:"ball"`
+	Balls     []BallState     `json:"balls"`
+	Current   PlayerState     `json:"current"`
+	Opponent  PlayerState     `json:"opponent"`
+	Paddles   []PaddleState   `json:"paddles"`
+	Obstacles []geometry.Rect `json:"obstacles,omitempty"`
+	PowerUps  []PowerUpState  `json:"power_ups,omitempty"`
+	Pickups   []PickupState   `json:"pickups,omitempty"`
+	Effects   []EffectState   `json:"effects,omitempty"`
+	// Events are the play events of this tick
+	Events       []PlayEvent `json:"events,omitempty"`
+	LongestRally int         `json:"longest_rally"`
+	Spectators   int         `json:"spectators"`
 }
 
+// BallState is a ball in play, identified by an ID unique within the session
+//
+// The Serve is set while the ball waits in the center of the field to be served.
 type BallState struct {
+	ID       int             `json:"id"`
+	Serve    *ServeState     `json:"serve,omitempty"`
 	Angle    float64         `json:"angle"`
 	Bounces  int             `json:"bounces"`
 	Position geometry.Vector `json:"position"`
@@ -29,12 +56,29 @@ type PlayerState struct {
 	Score     int8          `json:"score"`
 	Ping      int64         `json:"ping"`
 	Winner    bool          `json:"winner,omitempty"`
+	// Conceded and Eliminated are only set in the arena mode
+	Conceded   int8 `json:"conceded,omitempty"`
+	Eliminated bool `json:"eliminated,omitempty"`
+}
+
+// PaddleState is the position of a player's paddle, its top left corner, and its length along its wall
+//
+// The paddles of the top and bottom sides of the arena mode lie horizontally.
+type PaddleState struct {
+	Name       string          `json:"name"`
+	Side       geometry.Side   `json:"side"`
+	Position   geometry.Vector `json:"position"`
+	Length     float64         `json:"length"`
+	Ping       int64           `json:"ping"`
+	Eliminated bool            `json:"eliminated,omitempty"`
 }
 
-func ballState(ball ball.Ball) BallState {
+func ballState(b *ball) BallState {
 	return BallState{
-		Angle:    ball.Angle(),
-		Bounces:  ball.Bounces(),
-		Position: ball.Position(),
+		ID:       b.id,
+		Serve:    b.serveState(),
+		Angle:    b.Angle(),
+		Bounces:  b.Bounces(),
+		Position: b.Position(),
 	}
 }
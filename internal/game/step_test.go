@@ -0,0 +1,75 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/config"
+)
+
+// stepFrames steps a session of the mode from the seed for the ticks, with the same scripted
+// inputs every time, and returns its encoded game states
+func stepFrames(t *testing.T, mode Mode, seed uint64, ticks int) [][]byte {
+	t.Helper()
+
+	cfg := config.Default().Game
+	sides, size := mode.Sides(), mode.TeamSize()
+
+	players := make([]*Player, 0, len(sides)*size)
+	for i := range len(sides) * size {
+		network := NewNetwork(nil, GameInfo{
+			PlayerName:       string(rune('a' + i)),
+			Mode:             mode,
+			ScreenWidth:      640,
+			ScreenHeight:     480,
+			FieldBorderWidth: 10,
+			MaxScore:         100,
+		})
+		players = append(players, NewPlayer(network, sides[i/size], cfg))
+	}
+
+	session := NewGameSession(mode, nil, players, cfg, NewSessionManager(0), nil, nil,
+		WithSeed(seed),
+		WithClock(NewManualClock(time.Unix(0, 0))),
+	)
+
+	frames := make([][]byte, 0, ticks)
+	inputs := make([][]PlayerInput, len(players))
+	for tick := range ticks {
+		for i := range inputs {
+			direction := (tick/20 + i) % 3
+			inputs[i] = []PlayerInput{{Up: direction == 0, Down: direction == 1, Serve: true}}
+		}
+
+		frame, err := json.Marshal(session.Step(inputs))
+		if err != nil {
+			t.Fatalf("encoding the state of tick %d: %v", tick, err)
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func TestStepIsDeterministic(t *testing.T) {
+	const ticks = 2000
+
+	for _, mode := range []Mode{ModeClassic, ModeMultiball} {
+		t.Run(string(mode), func(t *testing.T) {
+			first, second := stepFrames(t, mode, 42, ticks), stepFrames(t, mode, 42, ticks)
+
+			for tick := range first {
+				if !bytes.Equal(first[tick], second[tick]) {
+					t.Fatalf("tick %d differs with the same seed:\n%s\n%s", tick, first[tick], second[tick])
+				}
+			}
+
+			other := stepFrames(t, mode, 43, ticks)
+			if bytes.Equal(first[ticks-1], other[ticks-1]) {
+				t.Error("sessions with different seeds end in the same state")
+			}
+		})
+	}
+}
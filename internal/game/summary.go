@@ -0,0 +1,58 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+// SessionSummary is the public description of a game session, shown to clients looking for matches to watch
+//
+// Unlike the SessionDetails, it holds no connection IDs or accounts.
+type SessionSummary struct {
+	ID         string          `json:"id"`
+	Mode       Mode            `json:"mode"`
+	Level      string          `json:"level"`
+	StartTime  time.Time       `json:"start_time"`
+	Players    []PlayerSummary `json:"players"`
+	Spectators int             `json:"spectators"`
+}
+
+type PlayerSummary struct {
+	Name  string        `json:"name"`
+	Side  geometry.Side `json:"side"`
+	Score int8          `json:"score"`
+}
+
+// Summary returns a public snapshot of the session that is safe to be taken outside the game loop
+func (session *GameSession) Summary() SessionSummary {
+	session.mutex.Lock()
+	summary := SessionSummary{
+		ID:        session.ID,
+		Mode:      session.Mode,
+		Level:     session.level.String(),
+		StartTime: session.startTime,
+		Players:   PlayerSummaries(session.playersDetails()),
+	}
+	session.mutex.Unlock()
+
+	session.spectatorMutex.Lock()
+	summary.Spectators = len(session.spectators)
+	session.spectatorMutex.Unlock()
+
+	return summary
+}
+
+// PlayerSummaries strips the given players details down to their public information
+func PlayerSummaries(players []PlayerDetails) []PlayerSummary {
+	summaries := make([]PlayerSummary, 0, len(players))
+	for _, details := range players {
+		summaries = append(summaries, PlayerSummary{
+			Name:  details.Name,
+			Side:  details.Side,
+			Score: details.Score,
+		})
+	}
+
+	return summaries
+}
@@ -0,0 +1,323 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/chat"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type EndSessionRequest struct {
+	// Winner is the side of the winning player: "left", "right", "top", "bottom" or "none"
+	Winner string `json:"winner"`
+}
+
+type BroadcastRequest struct {
+	Message string `json:"message"`
+}
+
+type BroadcastResponse struct {
+	Recipients int `json:"recipients"`
+}
+
+type ClearQueueResponse struct {
+	Removed int `json:"removed"`
+}
+
+type MuteRequest struct {
+	Kind   chat.MuteKind `json:"kind"`
+	Value  string        `json:"value"`
+	Reason string        `json:"reason"`
+	// Duration of the mute, e.g. "10m", the mute is permanent when empty
+	Duration string `json:"duration"`
+}
+
+// registerAdminRoutes sets up the administration endpoints
+//
+// Every route requires the configured admin token as a bearer token. When no
+// token is configured, the routes are not registered at all.
+func (s *Server) registerAdminRoutes() {
+	if s.adminToken == "" {
+		slog.Warn("Admin token not configured, admin routes disabled")
+		return
+	}
+
+	s.mux.HandleFunc("GET /admin/sessions", s.requireAdmin(s.handleAdminSessions))
+	s.mux.HandleFunc("GET /admin/sessions/{id}", s.requireAdmin(s.handleAdminSession))
+	s.mux.HandleFunc("POST /admin/sessions/{id}/end", s.requireAdmin(s.handleAdminEndSession))
+	s.mux.HandleFunc("DELETE /admin/sessions/{id}/players/{player}", s.requireAdmin(s.handleAdminKickPlayer))
+	s.mux.HandleFunc("DELETE /admin/sessions/{id}/spectators/{spectator}", s.requireAdmin(s.handleAdminKickSpectator))
+	s.mux.HandleFunc("DELETE /admin/queue", s.requireAdmin(s.handleAdminClearQueue))
+	s.mux.HandleFunc("POST /admin/broadcast", s.requireAdmin(s.handleAdminBroadcast))
+	s.mux.HandleFunc("GET /admin/bans", s.requireAdmin(s.handleAdminBans))
+	s.mux.HandleFunc("POST /admin/bans", s.requireAdmin(s.handleAdminBan))
+	s.mux.HandleFunc("DELETE /admin/bans/{kind}/{value}", s.requireAdmin(s.handleAdminUnban))
+	s.mux.HandleFunc("GET /admin/mutes", s.requireAdmin(s.handleAdminMutes))
+	s.mux.HandleFunc("POST /admin/mutes", s.requireAdmin(s.handleAdminMute))
+	s.mux.HandleFunc("DELETE /admin/mutes/{kind}/{value}", s.requireAdmin(s.handleAdminUnmute))
+}
+
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			slog.Warn("Unauthorized admin request", slog.String("path", r.URL.Path), slog.String("remote_addr", r.RemoteAddr))
+			writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+			return
+		}
+
+		slog.Info("Admin request", slog.String("method", r.Method), slog.String("path", r.URL.Path))
+		next(w, r)
+	}
+}
+
+// handleAdminSessions returns the details of every active session
+func (s *Server) handleAdminSessions(w http.ResponseWriter, r *http.Request) {
+	sessions := s.sessions.GetSessions()
+	details := make([]game.SessionDetails, 0, len(sessions))
+	for _, session := range sessions {
+		details = append(details, session.Details())
+	}
+
+	writeJSON(w, http.StatusOK, details)
+}
+
+// handleAdminSession returns the details of a single session
+func (s *Server) handleAdminSession(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.Session(r.PathValue("id"))
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session.Details())
+}
+
+// handleAdminEndSession forcefully ends a session with the requested result
+func (s *Server) handleAdminEndSession(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.Session(r.PathValue("id"))
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found"})
+		return
+	}
+
+	// An empty body ends the session without a winner
+	var request EndSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	var winnerSide geometry.Side
+	switch request.Winner {
+	case "left":
+		winnerSide = geometry.Left
+	case "right":
+		winnerSide = geometry.Right
+	case "top":
+		winnerSide = physics.Top
+	case "bottom":
+		winnerSide = physics.Bottom
+	case "none", "":
+		winnerSide = geometry.Undefined
+	default:
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "winner must be one of: left, right, top, bottom, none"})
+		return
+	}
+
+	session.ForceEnd(winnerSide)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminKickPlayer kicks a player from a session, which ends the session
+func (s *Server) handleAdminKickPlayer(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.Session(r.PathValue("id"))
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found"})
+		return
+	}
+
+	if !session.KickPlayer(r.PathValue("player")) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "player not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminKickSpectator kicks a spectator from a session
+func (s *Server) handleAdminKickSpectator(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.Session(r.PathValue("id"))
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found"})
+		return
+	}
+
+	if !session.KickSpectator(r.PathValue("spectator")) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "spectator not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminClearQueue disconnects every player waiting in the match queue
+func (s *Server) handleAdminClearQueue(w http.ResponseWriter, r *http.Request) {
+	players := s.wsServer.PlayerPool.Clear()
+	for _, player := range players {
+		player.Close("Match queue cleared by the server")
+	}
+
+	writeJSON(w, http.StatusOK, ClearQueueResponse{Removed: len(players)})
+}
+
+// handleAdminBroadcast sends a notice to every connected player and spectator
+func (s *Server) handleAdminBroadcast(w http.ResponseWriter, r *http.Request) {
+	var request BroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Message == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "message is required"})
+		return
+	}
+
+	recipients := s.wsServer.PlayerPool.Waiting()
+	for _, session := range s.sessions.GetSessions() {
+		for _, player := range session.Players {
+			recipients = append(recipients, player.Network)
+		}
+		recipients = append(recipients, session.Spectators()...)
+	}
+
+	notice := game.NoticeMessage{Notice: request.Message}
+	for _, recipient := range recipients {
+		if err := recipient.Send(notice); err != nil {
+			slog.Error("Error sending notice", slog.Any("error", err), slog.String("id", recipient.ID))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, BroadcastResponse{Recipients: len(recipients)})
+}
+
+// handleAdminBans returns the ban list
+func (s *Server) handleAdminBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.wsServer.Bans.List())
+}
+
+// handleAdminBan adds an entry to the ban list
+//
+// The ban only applies to new connections, connected clients must be kicked separately.
+func (s *Server) handleAdminBan(w http.ResponseWriter, r *http.Request) {
+	var ban access.Ban
+	if err := json.NewDecoder(r.Body).Decode(&ban); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	if err := s.wsServer.Bans.Add(ban); err != nil {
+		if errors.Is(err, access.ErrInvalidBan) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		slog.Error("Error saving ban list", slog.Any("error", err))
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "failed to save ban list"})
+		return
+	}
+
+	slog.Warn("Ban added", slog.Any("kind", ban.Kind), slog.String("value", ban.Value), slog.String("reason", ban.Reason))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminUnban removes an entry from the ban list
+func (s *Server) handleAdminUnban(w http.ResponseWriter, r *http.Request) {
+	kind, value := access.BanKind(r.PathValue("kind")), r.PathValue("value")
+
+	removed, err := s.wsServer.Bans.Remove(kind, value)
+	if err != nil {
+		slog.Error("Error saving ban list", slog.Any("error", err))
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "failed to save ban list"})
+		return
+	}
+
+	if !removed {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "ban not found"})
+		return
+	}
+
+	slog.Warn("Ban removed", slog.Any("kind", kind), slog.String("value", value))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminMutes returns the chat mutes
+func (s *Server) handleAdminMutes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.chat.Mutes())
+}
+
+// handleAdminMute mutes a name, account or IP in every chat
+func (s *Server) handleAdminMute(w http.ResponseWriter, r *http.Request) {
+	var request MuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	mute := chat.Mute{Kind: request.Kind, Value: request.Value, Reason: request.Reason}
+
+	if request.Duration != "" {
+		duration, err := time.ParseDuration(request.Duration)
+		if err != nil || duration <= 0 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "duration must be a positive duration, e.g. 10m"})
+			return
+		}
+
+		mute.Until = time.Now().Add(duration)
+	}
+
+	if err := s.chat.Mute(mute); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	slog.Warn("Chat mute added", slog.Any("kind", request.Kind), slog.String("value", request.Value), slog.String("reason", request.Reason), slog.String("duration", request.Duration))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminUnmute lifts a chat mute
+func (s *Server) handleAdminUnmute(w http.ResponseWriter, r *http.Request) {
+	kind, value := chat.MuteKind(r.PathValue("kind")), r.PathValue("value")
+
+	if !s.chat.Unmute(kind, value) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "mute not found"})
+		return
+	}
+
+	slog.Warn("Chat mute removed", slog.Any("kind", kind), slog.String("value", value))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		slog.Error("Error encoding response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,23 @@
+package httpserver
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// handleLayouts lists the field layouts players can queue for, sorted by name
+func (s *Server) handleLayouts(w http.ResponseWriter, r *http.Request) {
+	s.origins.SetCORSHeaders(w, r)
+
+	names := s.wsServer.Layouts.Names()
+	slices.Sort(names)
+
+	layouts := make([]*physics.Layout, 0, len(names))
+	for _, name := range names {
+		layouts = append(layouts, s.wsServer.Layouts[name])
+	}
+
+	writeJSON(w, http.StatusOK, layouts)
+}
@@ -2,42 +2,115 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/chat"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/game"
 	"github.com/reneepc/pongo-server/internal/ws"
 )
 
+// Server is the HTTP server
+//
+// It owns its routes multiplexer, so multiple servers can live in the same process.
 type Server struct {
-	httpServer *http.Server
+	httpServer      *http.Server
+	mux             *http.ServeMux
+	wsServer        *ws.Server
+	sessions        *game.SessionManager
+	chat            *chat.Moderator
+	adminToken      string
+	origins         *access.Origins
+	shutdownTimeout time.Duration
+	ctx             context.Context
+	cancel          context.CancelFunc
 }
 
-func New() *Server {
+// New creates the HTTP server and registers its routes
+//
+// The admin token protects the administration routes, which are disabled when it's empty.
+// The origins allowlist sets which browser origins may read the HTTP routes responses.
+func New(cfg config.HTTP, origins *access.Origins, wsServer *ws.Server, sessions *game.SessionManager, moderator *chat.Moderator) *Server {
+	mux := http.NewServeMux()
+
 	httpServer := &http.Server{
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Handler:      mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
 	}
 
-	return &Server{
-		httpServer: httpServer,
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Server{
+		httpServer:      httpServer,
+		mux:             mux,
+		wsServer:        wsServer,
+		sessions:        sessions,
+		chat:            moderator,
+		adminToken:      cfg.AdminToken,
+		origins:         origins,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
+
+	s.registerRoutes()
+
+	return s
 }
 
-func (s *Server) Start(addr string, wsServer *ws.Server) error {
-	http.HandleFunc("/multiplayer", wsServer.HandleConnections)
-	http.HandleFunc("/spectate", wsServer.HandleSpectatorConnections)
-	http.HandleFunc("/sessions", s.handleSessions)
+// Handler returns the handler serving every route, e.g. to be used with httptest
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
 
+func (s *Server) Start(addr string) error {
 	s.httpServer.Addr = addr
 
 	slog.Info("Server started", slog.String("addr", addr))
 	return s.httpServer.ListenAndServe()
 }
 
+// StartTLS serves the routes over TLS using the given certificate and key files
+//
+// The files are watched for changes, so renewed certificates are served without a restart.
+func (s *Server) StartTLS(addr string, certFile string, keyFile string) error {
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	go reloader.watch(s.ctx)
+
+	s.httpServer.Addr = addr
+	s.httpServer.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	slog.Info("Server started with TLS", slog.String("addr", addr), slog.String("cert_file", certFile))
+	return s.httpServer.ListenAndServeTLS("", "")
+}
+
+func (s *Server) registerRoutes() {
+	s.mux.HandleFunc("/multiplayer", s.wsServer.HandleConnections)
+	s.mux.HandleFunc("/spectate", s.wsServer.HandleSpectatorConnections)
+	s.mux.HandleFunc("/lobby", s.wsServer.HandleLobbyConnections)
+	s.mux.HandleFunc("/sessions", s.handleSessions)
+	s.mux.HandleFunc("GET /sessions/{id}", s.handleSession)
+	s.mux.HandleFunc("GET /layouts", s.handleLayouts)
+	s.registerAdminRoutes()
+}
+
 func (s *Server) Shutdown() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	s.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
 	return s.httpServer.Shutdown(ctx)
@@ -1,32 +1,294 @@
 package httpserver
 
 import (
-	"encoding/json"
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
 	"github.com/reneepc/pongo-server/internal/game"
 )
 
+const (
+	defaultSessionsLimit = 100
+	maxSessionsLimit     = 500
+)
+
+// SessionInfo is the public description of a session
+//
+// Player1 and Player2 are the names of the players of classic sessions, kept for
+// the clients that only need to list the sessions.
 type SessionInfo struct {
-	ID      string `json:"id"`
-	Player1 string `json:"player1"`
-	Player2 string `json:"player2"`
+	ID             string       `json:"id"`
+	Mode           game.Mode    `json:"mode"`
+	Player1        string       `json:"player1"`
+	Player2        string       `json:"player2"`
+	Level          string       `json:"level"`
+	StartTime      time.Time    `json:"start_time"`
+	Elapsed        int64        `json:"elapsed_ms"`
+	Spectators     int          `json:"spectators"`
+	SpectatorDelay int64        `json:"spectator_delay_ms"`
+	Players        []PlayerInfo `json:"players"`
+	// SpectatorList is only filled by the single session route
+	SpectatorList []SpectatorInfo `json:"spectator_list,omitempty"`
+}
+
+type SpectatorInfo struct {
+	JoinTime    time.Time        `json:"join_time"`
+	Perspective game.Perspective `json:"perspective,omitempty"`
+	Rate        int              `json:"rate,omitempty"`
+	Ping        int64            `json:"ping"`
+}
+
+type PlayerInfo struct {
+	Name  string        `json:"name"`
+	Side  geometry.Side `json:"side"`
+	Score int8          `json:"score"`
+	Ping  int64         `json:"ping"`
 }
 
-// handleSessions returns a list of active sessions
+// sessionsQuery holds the filters, order and page requested to the sessions route, a zero
+// limit returning every session
+type sessionsQuery struct {
+	mode   string
+	player string
+	level  string
+	order  sessionOrder
+	limit  int
+	cursor *sessionCursor
+}
+
+// sessionOrder sorts the sessions by a key, breaking ties by ID so the order is total
+type sessionOrder struct {
+	key        func(SessionInfo) int64
+	descending bool
+}
+
+// sessionCursor is the position of the last session of a page in the sessions order
+type sessionCursor struct {
+	key int64
+	id  string
+}
+
+var sessionKeys = map[string]func(SessionInfo) int64{
+	"start_time": func(info SessionInfo) int64 { return info.StartTime.UnixNano() },
+	"spectators": func(info SessionInfo) int64 { return int64(info.Spectators) },
+	"score": func(info SessionInfo) int64 {
+		var total int64
+		for _, player := range info.Players {
+			total += int64(player.Score)
+		}
+		return total
+	},
+}
+
+// handleSessions returns a page of the active sessions
+//
+// The sessions can be filtered by mode, player name and level, and sorted by start time,
+// spectators or total score. The cursor of the next page, if any, is returned in the
+// X-Next-Cursor header. Without a limit nor a cursor, every session is returned.
 func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Content-Type", "application/json")
+	s.origins.SetCORSHeaders(w, r)
+	w.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor")
+
+	query, err := parseSessionsQuery(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	sessions := game.GetSessionManager().GetSessions()
+	sessions := s.sessions.GetSessions()
 	sessionList := make([]SessionInfo, 0, len(sessions))
 	for _, session := range sessions {
-		sessionList = append(sessionList, SessionInfo{
-			ID:      session.ID,
-			Player1: session.Player1.PlayerName,
-			Player2: session.Player2.PlayerName,
+		info := sessionInfo(session.Details())
+		if query.matches(info) {
+			sessionList = append(sessionList, info)
+		}
+	}
+
+	sort.Slice(sessionList, func(i, j int) bool {
+		return query.order.before(query.order.cursor(sessionList[i]), query.order.cursor(sessionList[j]))
+	})
+
+	if query.cursor != nil {
+		start := sort.Search(len(sessionList), func(i int) bool {
+			return query.order.before(*query.cursor, query.order.cursor(sessionList[i]))
 		})
+		sessionList = sessionList[start:]
+	}
+
+	if query.limit > 0 && len(sessionList) > query.limit {
+		sessionList = sessionList[:query.limit]
+		w.Header().Set("X-Next-Cursor", query.order.cursor(sessionList[len(sessionList)-1]).String())
+	}
+
+	writeJSON(w, http.StatusOK, sessionList)
+}
+
+// handleSession returns a single session
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	s.origins.SetCORSHeaders(w, r)
+
+	session := s.sessions.Session(r.PathValue("id"))
+	if session == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found"})
+		return
+	}
+
+	details := session.Details()
+
+	info := sessionInfo(details)
+	info.SpectatorList = make([]SpectatorInfo, 0, len(details.Spectators))
+	for _, spectator := range details.Spectators {
+		info.SpectatorList = append(info.SpectatorList, SpectatorInfo{
+			JoinTime:    spectator.JoinTime,
+			Perspective: spectator.Perspective,
+			Rate:        spectator.Rate,
+			Ping:        spectator.Ping,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func sessionInfo(details game.SessionDetails) SessionInfo {
+	info := SessionInfo{
+		ID:             details.ID,
+		Mode:           details.Mode,
+		Level:          details.Level,
+		StartTime:      details.StartTime,
+		Elapsed:        details.Uptime,
+		Spectators:     len(details.Spectators),
+		SpectatorDelay: details.SpectatorDelay,
+		Players:        make([]PlayerInfo, 0, len(details.Players)),
+	}
+
+	for _, player := range details.Players {
+		info.Players = append(info.Players, PlayerInfo{
+			Name:  player.Name,
+			Side:  player.Side,
+			Score: player.Score,
+			Ping:  player.Ping,
+		})
+	}
+
+	if len(details.Players) == 2 {
+		info.Player1 = details.Players[0].Name
+		info.Player2 = details.Players[1].Name
+	}
+
+	return info
+}
+
+// parseSessionsQuery reads the mode, player, level, sort, limit and cursor query parameters
+//
+// The sort parameter is a key optionally prefixed by "-" for the descending order.
+func parseSessionsQuery(r *http.Request) (sessionsQuery, error) {
+	params := r.URL.Query()
+
+	query := sessionsQuery{
+		mode:   params.Get("mode"),
+		player: strings.ToLower(params.Get("player")),
+		level:  params.Get("level"),
+		order:  sessionOrder{key: sessionKeys["start_time"]},
+	}
+
+	if sortBy := params.Get("sort"); sortBy != "" {
+		name, descending := strings.CutPrefix(sortBy, "-")
+
+		key, ok := sessionKeys[name]
+		if !ok {
+			return query, errors.New("sort must be one of: start_time, spectators, score")
+		}
+
+		query.order = sessionOrder{key: key, descending: descending}
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return query, errors.New("limit must be a positive number")
+		}
+
+		query.limit = min(value, maxSessionsLimit)
+	}
+
+	if cursor := params.Get("cursor"); cursor != "" {
+		parsed, err := parseSessionCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+
+		query.cursor = &parsed
+
+		if query.limit == 0 {
+			query.limit = defaultSessionsLimit
+		}
+	}
+
+	return query, nil
+}
+
+func (q sessionsQuery) matches(info SessionInfo) bool {
+	if q.level != "" && !strings.EqualFold(q.level, info.Level) {
+		return false
+	}
+
+	if q.mode != "" && q.mode != string(info.Mode) {
+		return false
+	}
+
+	if q.player == "" {
+		return true
+	}
+
+	for _, player := range info.Players {
+		if strings.Contains(strings.ToLower(player.Name), q.player) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o sessionOrder) cursor(info SessionInfo) sessionCursor {
+	return sessionCursor{key: o.key(info), id: info.ID}
+}
+
+// before reports whether the position a comes before the position b in the order
+func (o sessionOrder) before(a sessionCursor, b sessionCursor) bool {
+	if a.key != b.key {
+		return (a.key < b.key) != o.descending
+	}
+
+	return a.id < b.id
+}
+
+func (c sessionCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.key, 10) + ":" + c.id))
+}
+
+func parseSessionCursor(value string) (sessionCursor, error) {
+	invalid := errors.New("invalid cursor")
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return sessionCursor{}, invalid
+	}
+
+	key, id, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return sessionCursor{}, invalid
+	}
+
+	parsedKey, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return sessionCursor{}, invalid
 	}
 
-	json.NewEncoder(w).Encode(sessionList)
+	return sessionCursor{key: parsedKey, id: id}, nil
 }
@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const certReloadInterval = 30 * time.Second
+
+// certReloader serves a TLS certificate loaded from files, reloading it whenever the files change
+//
+// It allows renewed certificates to be picked up without restarting the server.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+	sync.RWMutex
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	reloader := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// GetCertificate is meant to be used as the tls.Config certificate getter
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.cert, nil
+}
+
+// watch periodically checks the certificate files, reloading them when modified
+func (c *certReloader) watch(ctx context.Context) {
+	ticker := time.NewTicker(certReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := c.latestModTime()
+			if err != nil {
+				slog.Error("Error checking TLS certificate files", slog.Any("error", err))
+				continue
+			}
+
+			c.RLock()
+			changed := modTime.After(c.modTime)
+			c.RUnlock()
+
+			if !changed {
+				continue
+			}
+
+			if err := c.reload(); err != nil {
+				slog.Error("Error reloading TLS certificate, keeping the previous one", slog.Any("error", err))
+				continue
+			}
+
+			slog.Info("TLS certificate reloaded", slog.String("cert_file", c.certFile))
+		}
+	}
+}
+
+func (c *certReloader) reload() error {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.cert = &cert
+	c.modTime = modTime
+
+	return nil
+}
+
+func (c *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("checking TLS certificate file: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,236 @@
+package lobby
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/events"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/matchmaking"
+)
+
+const (
+	// clientQueueSize is the number of messages a client can fall behind before being dropped
+	clientQueueSize = 64
+	// writeTimeout is the time a client has to receive a message before being dropped
+	writeTimeout = 10 * time.Second
+)
+
+// Lobby message types
+const (
+	Snapshot       = "snapshot"
+	SessionStarted = "session_started"
+	ScoreChanged   = "score_changed"
+	SessionEnded   = "session_ended"
+	QueueChanged   = "queue_changed"
+)
+
+// SnapshotMessage is sent to a client once it joins the lobby, listing every session and the queue size
+type SnapshotMessage struct {
+	Type      string                `json:"type"`
+	Sessions  []game.SessionSummary `json:"sessions"`
+	QueueSize int                   `json:"queue_size"`
+}
+
+// SessionMessage is sent when a session's match starts
+type SessionMessage struct {
+	Type    string              `json:"type"`
+	Session game.SessionSummary `json:"session"`
+}
+
+// ScoreMessage is sent when a player scores, with the scores after the goal
+type ScoreMessage struct {
+	Type      string               `json:"type"`
+	SessionID string               `json:"session_id"`
+	Players   []game.PlayerSummary `json:"players"`
+}
+
+// EndMessage is sent when a session ends, with the final scores
+//
+// The winner is the winning side, which is undefined for sessions ended without a winner.
+type EndMessage struct {
+	Type      string               `json:"type"`
+	SessionID string               `json:"session_id"`
+	Players   []game.PlayerSummary `json:"players"`
+	Winner    geometry.Side        `json:"winner"`
+	Reason    game.EndReason       `json:"reason"`
+}
+
+// QueueMessage is sent when the number of players waiting in the match queue changes
+type QueueMessage struct {
+	Type      string `json:"type"`
+	QueueSize int    `json:"queue_size"`
+}
+
+// Lobby keeps its clients updated about the sessions and the match queue
+//
+// Clients receive a snapshot once they join, followed by the changes, which are fed to
+// the lobby by the event bus. The changes carry the whole state of what changed, e.g. the
+// scores after a goal instead of the goal itself, so an event that was already part of the
+// snapshot can safely be applied again.
+//
+// Every client has its own queue of messages, written to its connection by its own
+// goroutine, so a client that stops reading doesn't hold up the others. Clients that fall
+// behind their queue or don't receive a message in time are dropped.
+type Lobby struct {
+	sessions *game.SessionManager
+	pool     *matchmaking.PlayerPool
+	clients  []*client
+	mutex    sync.Mutex
+}
+
+// client is a lobby client with its pending messages, the stop channel being closed once
+// it leaves the lobby
+type client struct {
+	network *game.Network
+	queue   chan any
+	stop    chan struct{}
+}
+
+func New(sessions *game.SessionManager, pool *matchmaking.PlayerPool) *Lobby {
+	return &Lobby{
+		sessions: sessions,
+		pool:     pool,
+	}
+}
+
+// Join queues the snapshot to the client and subscribes it to the lobby changes
+func (l *Lobby) Join(network *game.Network) {
+	c := &client{
+		network: network,
+		queue:   make(chan any, clientQueueSize),
+		stop:    make(chan struct{}),
+	}
+
+	l.mutex.Lock()
+	c.queue <- l.snapshot()
+	l.clients = append(l.clients, c)
+	l.mutex.Unlock()
+
+	go l.write(c)
+}
+
+// Leave unsubscribes the client from the lobby changes
+func (l *Lobby) Leave(network *game.Network) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i, c := range l.clients {
+		if c.network == network {
+			l.clients = append(l.clients[:i], l.clients[i+1:]...)
+			close(c.stop)
+			return
+		}
+	}
+}
+
+// Close disconnects every client in the lobby, their connections being terminated by
+// their writers
+func (l *Lobby) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, c := range l.clients {
+		close(c.stop)
+	}
+	l.clients = nil
+}
+
+// write sends the queued messages to the client until it leaves the lobby or can't
+// receive them, and then terminates its connection
+func (l *Lobby) write(c *client) {
+	defer c.network.Terminate()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case message := <-c.queue:
+			c.network.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.network.Send(message); err != nil {
+				slog.Warn("Dropping lobby client", slog.Any("error", err), slog.String("id", c.network.ID))
+				l.Leave(c.network)
+				return
+			}
+		}
+	}
+}
+
+// Handle translates the server events into lobby changes and broadcasts them
+//
+// It's meant to be subscribed to the event bus.
+func (l *Lobby) Handle(event events.Event) {
+	switch data := event.Data.(type) {
+	case game.MatchEvent:
+		if event.Type != events.MatchStarted {
+			return
+		}
+
+		// Sessions ending right after starting are only notified by their end
+		session := l.sessions.Session(event.SessionID)
+		if session == nil {
+			return
+		}
+
+		l.broadcast(SessionMessage{Type: SessionStarted, Session: session.Summary()})
+	case game.GoalEvent:
+		l.broadcast(ScoreMessage{
+			Type:      ScoreChanged,
+			SessionID: event.SessionID,
+			Players:   game.PlayerSummaries(data.Players),
+		})
+	case game.MatchResult:
+		message := EndMessage{
+			Type:      SessionEnded,
+			SessionID: event.SessionID,
+			Players:   game.PlayerSummaries(data.Players),
+			Winner:    data.WinnerSide,
+			Reason:    data.Reason,
+		}
+
+		l.broadcast(message)
+	case game.QueueSizeEvent:
+		l.broadcast(QueueMessage{Type: QueueChanged, QueueSize: data.QueueSize})
+	}
+}
+
+func (l *Lobby) snapshot() SnapshotMessage {
+	sessions := l.sessions.GetSessions()
+
+	summaries := make([]game.SessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		summaries = append(summaries, session.Summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartTime.Before(summaries[j].StartTime)
+	})
+
+	return SnapshotMessage{
+		Type:      Snapshot,
+		Sessions:  summaries,
+		QueueSize: len(l.pool.Waiting()),
+	}
+}
+
+// broadcast queues the message to every client, dropping the clients whose queue is full
+func (l *Lobby) broadcast(message any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	clients := l.clients[:0]
+	for _, c := range l.clients {
+		select {
+		case c.queue <- message:
+			clients = append(clients, c)
+		default:
+			slog.Warn("Dropping lobby client falling behind", slog.String("id", c.network.ID))
+			close(c.stop)
+		}
+	}
+
+	l.clients = clients
+}
@@ -0,0 +1,22 @@
+package matchmaking
+
+import "github.com/reneepc/pongo-server/internal/game"
+
+// Matcher selects which of the players waiting in the queue for the classic mode play together
+//
+// The players are ordered by the time they joined the queue. Returning nil
+// players means that no match should be started at the moment.
+type Matcher interface {
+	Match(players []*game.Network) (*game.Network, *game.Network)
+}
+
+// FIFOMatcher matches the two players waiting for the longest time
+type FIFOMatcher struct{}
+
+func (FIFOMatcher) Match(players []*game.Network) (*game.Network, *game.Network) {
+	if len(players) < 2 {
+		return nil, nil
+	}
+
+	return players[0], players[1]
+}
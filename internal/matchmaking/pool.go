@@ -1,26 +1,54 @@
 package matchmaking
 
 import (
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/chat"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/events"
 	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
 )
 
+// matchInterval is how often the queue is checked for matches besides when players join
+const matchInterval = time.Second
+
 // PlayerPool is the pool of unmatched players waiting in the match queue
+//
+// The game configuration, chat moderator and event bus are handed to every game session
+// started by the pool, and the sessions are registered in the given session manager. The
+// matcher decides which players are matched together, and players are only matched with
+// players queued for the same mode and field layout. Players waiting in the queue share
+// the lobby chat.
 type PlayerPool struct {
 	sync.Mutex
 	Players     []*game.Network
 	matchSignal chan struct{}
+	done        chan struct{}
+	stopOnce    sync.Once
+	gameConfig  config.Game
+	layouts     physics.Layouts
+	sessions    *game.SessionManager
+	matcher     Matcher
+	chat        *chat.Moderator
+	events      *events.Bus
 }
 
-func NewPlayerPool() *PlayerPool {
+func NewPlayerPool(cfg config.Game, layouts physics.Layouts, sessions *game.SessionManager, matcher Matcher, moderator *chat.Moderator, bus *events.Bus) *PlayerPool {
 	pool := &PlayerPool{
-		Players: make([]*game.Network, 0),
+		Players:    make([]*game.Network, 0),
+		gameConfig: cfg,
+		layouts:    layouts,
+		sessions:   sessions,
+		matcher:    matcher,
+		chat:       moderator,
+		events:     bus,
 	}
 
-	pool.matchSignal = make(chan struct{})
+	pool.matchSignal = make(chan struct{}, 1)
+	pool.done = make(chan struct{})
 
 	go pool.StartMatchmaking()
 
@@ -33,9 +61,26 @@ func (p *PlayerPool) AddPlayer(player *game.Network) {
 
 	player.JoinTime = time.Now()
 
+	player.SetHandler(func(message game.ClientMessage) {
+		if message.Chat != "" {
+			game.Chat(p.chat, game.ChannelLobby, player, message.Chat, p.Waiting())
+		}
+	})
+
 	p.Players = append(p.Players, player)
 
-	p.matchSignal <- struct{}{}
+	p.events.Publish(events.New(events.PlayerQueued, "", game.QueueEvent{
+		Player:    player.Details(),
+		QueueSize: len(p.Players),
+	}))
+	p.publishQueueSize()
+
+	// A pending signal already covers this player, and waiting for the matchmaking loop
+	// while holding the lock would deadlock it.
+	select {
+	case p.matchSignal <- struct{}{}:
+	default:
+	}
 }
 
 func (p *PlayerPool) RemovePlayer(player *game.Network) {
@@ -45,56 +90,247 @@ func (p *PlayerPool) RemovePlayer(player *game.Network) {
 	for i, poolPlayer := range p.Players {
 		if poolPlayer == player {
 			p.Players = append(p.Players[:i], p.Players[i+1:]...)
+			p.publishQueueSize()
 			return
 		}
 	}
 }
 
-// FindMatch finds a match for two players and removes them from the pool
-func (p *PlayerPool) FindMatch() (*game.Network, *game.Network) {
+// Waiting returns a copy of the players currently waiting in the match queue
+func (p *PlayerPool) Waiting() []*game.Network {
+	p.Lock()
+	defer p.Unlock()
+
+	players := make([]*game.Network, len(p.Players))
+	copy(players, p.Players)
+
+	return players
+}
+
+// Clear removes every player from the match queue and returns the removed players
+func (p *PlayerPool) Clear() []*game.Network {
+	p.Lock()
+	defer p.Unlock()
+
+	players := p.Players
+	p.Players = make([]*game.Network, 0)
+
+	if len(players) > 0 {
+		p.publishQueueSize()
+	}
+
+	return players
+}
+
+// Match is a group of queued players to be started in a session, ordered by side, the left side first
+type Match struct {
+	Mode    game.Mode
+	Layout  string
+	Players []*game.Network
+}
+
+// FindMatch finds a match among the queued players and removes them from the pool
+//
+// Classic matches are chosen by the pool's matcher, and the matches of the other modes
+// are formed by the parties and solo players waiting for the longest time. The layouts
+// are tried in the order they were first queued for.
+func (p *PlayerPool) FindMatch() *Match {
 	p.Lock()
 	defer p.Unlock()
 
-	if len(p.Players) < 2 {
-		return nil, nil
+	var match *Match
+	for _, layout := range p.queuedLayouts() {
+		if match == nil {
+			match = p.findClassicMatch(layout)
+		}
+
+		for _, mode := range []game.Mode{game.ModeDoubles, game.ModeDoublesOpen, game.ModeArena, game.ModeMultiball} {
+			if match == nil {
+				match = p.findTeamMatch(mode, layout)
+			}
+		}
 	}
 
-	p1, p2 := p.Players[0], p.Players[1]
-	p.Players = p.Players[2:]
+	if match == nil {
+		return nil
+	}
+
+	matched := make(map[*game.Network]bool, len(match.Players))
+	for _, player := range match.Players {
+		matched[player] = true
+	}
+
+	remaining := make([]*game.Network, 0, len(p.Players))
+	for _, player := range p.Players {
+		if !matched[player] {
+			remaining = append(remaining, player)
+		}
+	}
 
-	return p1, p2
+	// The matcher must pick distinct players from the queue
+	if len(remaining) != len(p.Players)-len(match.Players) {
+		return nil
+	}
+
+	p.Players = remaining
+	p.publishQueueSize()
+
+	return match
+}
+
+// findClassicMatch asks the matcher for two of the players queued for the classic mode on the layout
+func (p *PlayerPool) findClassicMatch(layout string) *Match {
+	players := p.queuedFor(game.ModeClassic, layout)
+	if len(players) < 2 {
+		return nil
+	}
+
+	p1, p2 := p.matcher.Match(players)
+	if p1 == nil || p2 == nil || p1 == p2 {
+		return nil
+	}
+
+	return &Match{Mode: game.ModeClassic, Layout: layout, Players: []*game.Network{p1, p2}}
+}
+
+// findTeamMatch forms a team for every side of the mode among the players queued for it on the layout
+//
+// Players of the same party are kept together, and a party waits until all of its players
+// are queued. Parties and solo players are taken in the order they joined the queue, solo
+// players completing the teams with other solo players.
+func (p *PlayerPool) findTeamMatch(mode game.Mode, layout string) *Match {
+	size, sides := mode.TeamSize(), len(mode.Sides())
+
+	var teams [][]*game.Network
+	var solos []*game.Network
+	parties := make(map[string][]*game.Network)
+
+	for _, player := range p.queuedFor(mode, layout) {
+		if len(teams) == sides {
+			break
+		}
+
+		if player.Party == "" {
+			solos = append(solos, player)
+			if len(solos) == size {
+				teams = append(teams, solos)
+				solos = nil
+			}
+			continue
+		}
+
+		party := append(parties[player.Party], player)
+		parties[player.Party] = party
+		if len(party) == size {
+			teams = append(teams, party)
+			delete(parties, player.Party)
+		}
+	}
+
+	if len(teams) < sides {
+		return nil
+	}
+
+	match := &Match{Mode: mode, Layout: layout}
+	for _, team := range teams {
+		match.Players = append(match.Players, team...)
+	}
+
+	return match
+}
+
+// queuedFor returns the players queued for the mode on the layout, it must be called with the pool locked
+func (p *PlayerPool) queuedFor(mode game.Mode, layout string) []*game.Network {
+	players := make([]*game.Network, 0, len(p.Players))
+	for _, player := range p.Players {
+		if player.GameMode() == mode && player.Layout == layout {
+			players = append(players, player)
+		}
+	}
+
+	return players
+}
+
+// queuedLayouts returns the layouts the players are queued for, in the order they were
+// first queued for, it must be called with the pool locked
+func (p *PlayerPool) queuedLayouts() []string {
+	var layouts []string
+	for _, player := range p.Players {
+		if !slices.Contains(layouts, player.Layout) {
+			layouts = append(layouts, player.Layout)
+		}
+	}
+
+	return layouts
+}
+
+// publishQueueSize notifies the current size of the match queue, it must be called with the pool locked
+func (p *PlayerPool) publishQueueSize() {
+	p.events.Publish(events.New(events.QueueChanged, "", game.QueueSizeEvent{QueueSize: len(p.Players)}))
 }
 
 // StartMatchmaking is responsible for constantly checking the match queue for players
-// and starting a new game session when two players are found.
+// and starting new game sessions while matches are found.
 //
 // The matchSignal channel is used to trigger the matchmaking process and it's supposed to be
-// triggered every time a new player joins the pool. Otherwise, it will block.
+// triggered every time a new player joins the pool. It holds at most one pending signal.
+// The queue is also checked periodically, allowing matchers to take the waiting time into
+// account.
 //
-// The matching process only considers the first two players in the queue.
+// It runs until the pool is stopped.
 func (p *PlayerPool) StartMatchmaking() {
+	ticker := time.NewTicker(matchInterval)
+	defer ticker.Stop()
+
 	for {
-		<-p.matchSignal
+		select {
+		case <-p.done:
+			return
+		case <-p.matchSignal:
+		case <-ticker.C:
+		}
 
-		p1, p2 := p.FindMatch()
-		if p1 == nil || p2 == nil {
-			continue
+		for {
+			match := p.FindMatch()
+			if match == nil {
+				break
+			}
+
+			p.startNewGameSession(match)
 		}
+	}
+}
+
+// Stop ends the matchmaking and terminates the connections of the players waiting in the queue
+//
+// Stopping a stopped pool does nothing.
+func (p *PlayerPool) Stop() {
+	p.stopOnce.Do(func() { close(p.done) })
 
-		startNewGameSession(p1, p2)
+	for _, player := range p.Clear() {
+		player.Terminate()
 	}
 }
 
-func startNewGameSession(p1, p2 *game.Network) {
-	player1 := game.NewPlayer(p1, geometry.Left)
-	player2 := game.NewPlayer(p2, geometry.Right)
+// startNewGameSession starts the session of the match, its teams defending the mode's sides in order
+func (p *PlayerPool) startNewGameSession(match *Match) {
+	sides := match.Mode.Sides()
+	size := match.Mode.TeamSize()
 
-	session := game.NewGameSession(player1, player2)
+	players := make([]*game.Player, 0, len(match.Players))
+	details := make([]game.PlayerDetails, 0, len(match.Players))
+	for i, network := range match.Players {
+		players = append(players, game.NewPlayer(network, sides[i/size], p.gameConfig))
+		details = append(details, network.Details())
+	}
 
-	game.GetSessionManager().AddSession(session.ID, session)
+	session := game.NewGameSession(match.Mode, p.layouts[match.Layout], players, p.gameConfig, p.sessions, p.chat, p.events)
 
-	go session.Start()
+	p.events.Publish(events.New(events.PlayersMatched, session.ID, game.MatchEvent{
+		Players: details,
+	}))
 
-	player1.StartInputReader()
-	player2.StartInputReader()
+	p.sessions.AddSession(session.ID, session)
+
+	go session.Start()
 }
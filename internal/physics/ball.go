@@ -0,0 +1,300 @@
+package physics
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/engine/level"
+	"github.com/gandarez/pong-multiplayer-go/pkg/engine/player"
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+const (
+	// BallWidth is the width and height of the balls
+	BallWidth    = 10
+	initialSpeed = 2
+	maxSpeed     = 8
+)
+
+// Collision describes what the ball hit during an update
+//
+// The Paddle and the Obstacle are the indexes of the paddle and the field obstacle the
+// ball bounced on, or -1. The Wall is the wall the ball bounced on, and the Goal is the
+// goal wall the ball left the field through, both geometry.Undefined when none.
+type Collision struct {
+	Paddle   int
+	Obstacle int
+	Wall     geometry.Side
+	Goal     geometry.Side
+}
+
+// Ball is the server side ball, moving across a field with any number of paddles
+//
+// It follows the physics of the engine's local ball, so the classic matches play the
+// same on the clients and the server: the angle is in degrees, the ball bounces at a
+// margin of its own width from the walls, and it speeds up every other bounce. Unlike
+// the engine's ball, it bounces on all four walls and paddles, it's served toward a
+// chosen side, its randomness comes from the given source, and it follows the bounce
+// rules of the field.
+type Ball struct {
+	position geometry.Vector
+	angle    float64
+	speed    float64
+	bounces  int
+	level    level.Level
+	field    *Field
+	rand     *rand.Rand
+}
+
+// NewBall creates a ball at rest in the center of the field, waiting to be served
+func NewBall(field *Field, lvl level.Level, random *rand.Rand) *Ball {
+	ball := &Ball{
+		level: lvl,
+		field: field,
+		rand:  random,
+	}
+	ball.place()
+
+	return ball
+}
+
+// Serve puts the ball back in the center of the field, moving toward the given side
+//
+// The angle is random within 45 degrees of the direction of the side.
+func (b *Ball) Serve(toward geometry.Side) {
+	b.place()
+	b.angle = direction(toward) - 45 + float64(b.rand.IntN(91))
+}
+
+// Angle returns the direction of the ball in degrees, clockwise from the right
+func (b *Ball) Angle() float64 {
+	return b.angle
+}
+
+// Bounces returns the number of bounces since the ball was served
+func (b *Ball) Bounces() int {
+	return b.bounces
+}
+
+// Speed returns the distance covered by the ball on each update
+func (b *Ball) Speed() float64 {
+	return b.speed
+}
+
+// Position returns the top left corner of the ball
+func (b *Ball) Position() geometry.Vector {
+	return b.position
+}
+
+// Width returns the width of the ball
+func (b *Ball) Width() float64 {
+	return BallWidth
+}
+
+// Boost speeds the ball up by the given amount, up to its maximum speed
+func (b *Ball) Boost(amount float64) {
+	b.speed = min(b.speed+amount, maxSpeed)
+}
+
+// Bounds returns the rectangle covered by the ball
+func (b *Ball) Bounds() geometry.Rect {
+	return geometry.Rect{X: b.position.X, Y: b.position.Y, Width: BallWidth, Height: BallWidth}
+}
+
+// Update moves the ball and bounces it on the walls and the paddles, reporting what it hit
+//
+// The paddles are checked in order, and the ball bounces on the first it collides with.
+func (b *Ball) Update(paddles []player.Player) Collision {
+	collision := Collision{Paddle: -1, Obstacle: -1}
+
+	b.position.X += b.speed * math.Cos(b.angle*math.Pi/180)
+	b.position.Y += b.speed * math.Sin(b.angle*math.Pi/180)
+
+	collision.Wall = b.bounceOnWalls()
+	collision.Obstacle = b.bounceOnObstacles()
+
+	for i, paddle := range paddles {
+		if b.collides(paddle.Bounds()) {
+			b.bounceOnPaddle(paddle)
+			collision.Paddle = i
+			break
+		}
+	}
+
+	collision.Goal = b.goal()
+
+	return collision
+}
+
+// center returns the center of the ball
+func (b *Ball) center() geometry.Vector {
+	return geometry.Vector{X: b.position.X + BallWidth/2, Y: b.position.Y + BallWidth/2}
+}
+
+// place puts the ball at rest in the center of the field
+func (b *Ball) place() {
+	b.position = geometry.Vector{
+		X: (b.field.Width - BallWidth) / 2,
+		Y: (b.field.Height - BallWidth) / 2,
+	}
+	b.speed = initialSpeed
+	b.bounces = 0
+}
+
+// bounceOnWalls bounces the ball on the walls, except through the goals
+func (b *Ball) bounceOnWalls() geometry.Side {
+	var wall geometry.Side
+
+	center := b.center()
+
+	switch {
+	case !b.field.scores(Top, center) && b.position.Y <= BallWidth:
+		wall = Top
+		b.position.Y = BallWidth
+		b.angle *= -1
+	case !b.field.scores(Bottom, center) && b.position.Y >= b.field.Height-2*BallWidth:
+		wall = Bottom
+		b.position.Y = b.field.Height - 2*BallWidth
+		b.angle *= -1
+	case !b.field.scores(geometry.Left, center) && b.position.X <= BallWidth:
+		wall = geometry.Left
+		b.position.X = BallWidth
+		b.angle = 180 - b.angle
+	case !b.field.scores(geometry.Right, center) && b.position.X >= b.field.Width-2*BallWidth:
+		wall = geometry.Right
+		b.position.X = b.field.Width - 2*BallWidth
+		b.angle = 180 - b.angle
+	default:
+		return geometry.Undefined
+	}
+
+	b.bounce()
+
+	return wall
+}
+
+// bounceOnObstacles bounces the ball off the first obstacle it overlaps, returning its index or -1
+//
+// The ball is pushed out of the obstacle through the side it overlaps the least.
+func (b *Ball) bounceOnObstacles() int {
+	ball := b.Bounds()
+
+	for i, obstacle := range b.field.Obstacles {
+		bounds := obstacle.Bounds()
+		if !b.collides(bounds) {
+			continue
+		}
+
+		left, right := ball.MaxX()-bounds.X, bounds.MaxX()-ball.X
+		top, bottom := ball.MaxY()-bounds.Y, bounds.MaxY()-ball.Y
+
+		switch min(left, right, top, bottom) {
+		case left:
+			b.position.X = bounds.X - BallWidth
+			b.angle = 180 - b.angle
+		case right:
+			b.position.X = bounds.MaxX()
+			b.angle = 180 - b.angle
+		case top:
+			b.position.Y = bounds.Y - BallWidth
+			b.angle *= -1
+		default:
+			b.position.Y = bounds.MaxY()
+			b.angle *= -1
+		}
+
+		b.bounce()
+
+		return i
+	}
+
+	return -1
+}
+
+// bounce deviates the ball after bouncing on a wall or an obstacle, following the field's rules
+func (b *Ball) bounce() {
+	// slight random adjustment to avoid flat bounces
+	b.angle += b.field.Bounce.Jitter * (b.rand.Float64() - 0.5)
+	b.bounces++
+
+	if b.field.Bounce.SpeedUp {
+		b.increaseSpeed()
+	}
+}
+
+// bounceOnPaddle sends the ball back from the paddle's wall, with a random deviation
+func (b *Ball) bounceOnPaddle(paddle player.Player) {
+	bounds := paddle.Bounds()
+
+	switch paddle.Side() {
+	case geometry.Left:
+		b.position.X = bounds.MaxX() + BallWidth
+	case geometry.Right:
+		b.position.X = bounds.X - BallWidth
+	case Top:
+		b.position.Y = bounds.MaxY() + BallWidth
+	case Bottom:
+		b.position.Y = bounds.Y - BallWidth
+	}
+
+	if paddle.Side() == Top || paddle.Side() == Bottom {
+		b.angle = -b.angle - 10 + 20*b.rand.Float64()
+	} else {
+		b.angle = 180 - b.angle - 10 + 20*b.rand.Float64()
+	}
+
+	b.bounces++
+	b.increaseSpeed()
+}
+
+func (b *Ball) collides(paddle geometry.Rect) bool {
+	return b.position.X+BallWidth >= paddle.X && b.position.X <= paddle.MaxX() &&
+		b.position.Y+BallWidth >= paddle.Y && b.position.Y <= paddle.MaxY()
+}
+
+// goal returns the goal wall the ball left the field through, if any
+func (b *Ball) goal() geometry.Side {
+	switch {
+	case b.field.Goals[geometry.Left] && b.position.X+BallWidth <= 0:
+		return geometry.Left
+	case b.field.Goals[geometry.Right] && b.position.X >= b.field.Width:
+		return geometry.Right
+	case b.field.Goals[Top] && b.position.Y+BallWidth <= 0:
+		return Top
+	case b.field.Goals[Bottom] && b.position.Y >= b.field.Height:
+		return Bottom
+	default:
+		return geometry.Undefined
+	}
+}
+
+func (b *Ball) increaseSpeed() {
+	if b.bounces%2 != 0 {
+		return
+	}
+
+	switch b.level {
+	case level.Easy:
+		b.speed += 0.5
+	case level.Medium:
+		b.speed++
+	case level.Hard:
+		b.speed += 2
+	}
+
+	b.speed = min(b.speed, maxSpeed)
+}
+
+// direction returns the angle pointing from the center of the field toward the side
+func direction(side geometry.Side) float64 {
+	switch side {
+	case geometry.Left:
+		return 180
+	case Top:
+		return 270
+	case Bottom:
+		return 90
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,108 @@
+package physics
+
+import "github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+
+const (
+	// Top is the top wall of the field, defended in the arena mode
+	Top geometry.Side = iota + geometry.Left + 1
+	// Bottom is the bottom wall of the field, defended in the arena mode
+	Bottom
+)
+
+// Field is the rectangle the ball moves in
+//
+// The ball leaves the field through the goal walls, scoring against the players
+// defending them, and bounces on the other walls. The Border is the width of the
+// walls, which the paddles don't cross.
+//
+// A layout adds obstacles to the field, narrows its goals to the middle of the goal
+// walls, and changes the rules of the bounces.
+type Field struct {
+	Width     float64
+	Height    float64
+	Border    float64
+	Goals     map[geometry.Side]bool
+	GoalWidth float64
+	Bounce    Bounce
+	Obstacles []*Obstacle
+}
+
+// NewField creates a field whose goals are the given walls
+func NewField(width float64, height float64, border float64, goals ...geometry.Side) *Field {
+	field := &Field{
+		Width:  width,
+		Height: height,
+		Border: border,
+		Goals:  make(map[geometry.Side]bool, len(goals)),
+		Bounce: DefaultBounce,
+	}
+
+	for _, side := range goals {
+		field.Goals[side] = true
+	}
+
+	return field
+}
+
+// SetLayout applies the layout to the field, placing its obstacles
+func (f *Field) SetLayout(layout *Layout) {
+	f.GoalWidth = layout.GoalWidth
+	f.Bounce = layout.Bounce
+	f.Obstacles = make([]*Obstacle, 0, len(layout.Obstacles))
+
+	for _, spec := range layout.Obstacles {
+		obstacle := &Obstacle{
+			bounds: geometry.Rect{
+				X:      spec.X * f.Width,
+				Y:      spec.Y * f.Height,
+				Width:  spec.Width * f.Width,
+				Height: spec.Height * f.Height,
+			},
+			forward: true,
+		}
+		obstacle.from = geometry.Vector{X: obstacle.bounds.X, Y: obstacle.bounds.Y}
+
+		if spec.To != nil {
+			obstacle.to = geometry.Vector{X: spec.To.X * f.Width, Y: spec.To.Y * f.Height}
+			obstacle.speed = spec.Speed
+		}
+
+		f.Obstacles = append(f.Obstacles, obstacle)
+	}
+}
+
+// Update moves the moving obstacles of the field
+func (f *Field) Update() {
+	for _, obstacle := range f.Obstacles {
+		obstacle.update()
+	}
+}
+
+// scores reports whether the ball centered at the given point of the side's wall
+// leaves the field through it instead of bouncing
+func (f *Field) scores(side geometry.Side, center geometry.Vector) bool {
+	if !f.Goals[side] {
+		return false
+	}
+
+	if f.GoalWidth == 0 {
+		return true
+	}
+
+	along, length := center.Y, f.Height
+	if side == Top || side == Bottom {
+		along, length = center.X, f.Width
+	}
+
+	return along >= length*(1-f.GoalWidth)/2 && along <= length*(1+f.GoalWidth)/2
+}
+
+// Close turns the goal of the side into a wall, which the ball bounces on
+func (f *Field) Close(side geometry.Side) {
+	delete(f.Goals, side)
+}
+
+// Open turns the wall of the side back into a goal
+func (f *Field) Open(side geometry.Side) {
+	f.Goals[side] = true
+}
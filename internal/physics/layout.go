@@ -0,0 +1,203 @@
+package physics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+var ErrUnknownLayout = errors.New("unknown field layout")
+
+// Layout is a custom field layout, with obstacles, goal widths and wall bounce rules
+//
+// Positions and sizes are fractions of the field width and height, so a layout fits any
+// field. The GoalWidth is the fraction of the goal walls open in their middle, the rest
+// of the walls bouncing the ball, and zero opens the whole walls.
+type Layout struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	GoalWidth   float64        `json:"goal_width,omitempty"`
+	Bounce      Bounce         `json:"bounce"`
+	Obstacles   []ObstacleSpec `json:"obstacles,omitempty"`
+}
+
+// Bounce are the rules of the ball bouncing on the walls and obstacles
+//
+// The Jitter is the maximum random deviation of the bounces in degrees, and SpeedUp
+// counts the bounces toward the ball speed-ups, as the paddle bounces do.
+type Bounce struct {
+	Jitter  float64 `json:"jitter"`
+	SpeedUp bool    `json:"speed_up"`
+}
+
+// DefaultBounce are the bounce rules of the plain field, the ones of the engine's local ball
+var DefaultBounce = Bounce{Jitter: 5, SpeedUp: true}
+
+// ObstacleSpec is an obstacle rectangle of a layout, its position being its top left corner
+//
+// Moving obstacles slide back and forth between the position and the To position, at the
+// given Speed in pixels per update.
+type ObstacleSpec struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	To     *Point  `json:"to,omitempty"`
+	Speed  float64 `json:"speed,omitempty"`
+}
+
+// Point is a position on the field, in fractions of the field width and height
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Layouts are the field layouts available to the players, by name
+type Layouts map[string]*Layout
+
+// Get returns the layout with the given name, the empty name being the plain field
+func (l Layouts) Get(name string) (*Layout, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	layout, ok := l[name]
+	if !ok {
+		return nil, ErrUnknownLayout
+	}
+
+	return layout, nil
+}
+
+// Names returns the names of the available layouts
+func (l Layouts) Names() []string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// LoadLayouts reads the layouts of every JSON file of the directory
+//
+// A layout without a name is named after its file. An empty directory name loads no layouts.
+func LoadLayouts(dir string) (Layouts, error) {
+	layouts := make(Layouts)
+	if dir == "" {
+		return layouts, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		layout := &Layout{Bounce: DefaultBounce}
+		if err := json.Unmarshal(data, layout); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if layout.Name == "" {
+			layout.Name = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+
+		if err := layout.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if _, ok := layouts[layout.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate layout name %q", file, layout.Name)
+		}
+
+		layouts[layout.Name] = layout
+	}
+
+	return layouts, nil
+}
+
+// Validate returns every invalid setting of the layout
+func (l *Layout) Validate() error {
+	var errs []error
+
+	if l.GoalWidth < 0 || l.GoalWidth > 1 {
+		errs = append(errs, fmt.Errorf("goal_width must be between 0 and 1, got %v", l.GoalWidth))
+	}
+
+	if l.Bounce.Jitter < 0 || l.Bounce.Jitter > 45 {
+		errs = append(errs, fmt.Errorf("bounce.jitter must be between 0 and 45, got %v", l.Bounce.Jitter))
+	}
+
+	for i, obstacle := range l.Obstacles {
+		positions := []Point{{X: obstacle.X, Y: obstacle.Y}}
+		if obstacle.To != nil {
+			positions = append(positions, *obstacle.To)
+		}
+
+		for _, position := range positions {
+			if position.X < 0 || position.Y < 0 || position.X+obstacle.Width > 1 || position.Y+obstacle.Height > 1 {
+				errs = append(errs, fmt.Errorf("obstacles[%d] must be within the field", i))
+			}
+		}
+
+		if obstacle.Width <= 0 || obstacle.Height <= 0 {
+			errs = append(errs, fmt.Errorf("obstacles[%d] must have a positive width and height", i))
+		}
+
+		if obstacle.Speed < 0 {
+			errs = append(errs, fmt.Errorf("obstacles[%d].speed must not be negative, got %v", i, obstacle.Speed))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Obstacle is an obstacle rectangle on the field, bouncing the ball
+type Obstacle struct {
+	bounds  geometry.Rect
+	from    geometry.Vector
+	to      geometry.Vector
+	speed   float64
+	forward bool
+}
+
+// Bounds returns the rectangle covered by the obstacle
+func (o *Obstacle) Bounds() geometry.Rect {
+	return o.bounds
+}
+
+// update slides a moving obstacle toward its current target, turning back at the ends
+func (o *Obstacle) update() {
+	if o.speed == 0 {
+		return
+	}
+
+	target := o.from
+	if o.forward {
+		target = o.to
+	}
+
+	dx, dy := target.X-o.bounds.X, target.Y-o.bounds.Y
+	distance := math.Hypot(dx, dy)
+
+	if distance <= o.speed {
+		o.bounds.X, o.bounds.Y = target.X, target.Y
+		o.forward = !o.forward
+		return
+	}
+
+	o.bounds.X += dx / distance * o.speed
+	o.bounds.Y += dy / distance * o.speed
+}
@@ -0,0 +1,156 @@
+package physics
+
+import (
+	"github.com/gandarez/pong-multiplayer-go/pkg/engine/player"
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+)
+
+const (
+	paddleLength   = 50
+	paddleWidth    = 10
+	paddleDistance = 15
+)
+
+// Paddle is a player's paddle defending a wall of the field
+//
+// The paddles of the left and right walls follow the engine's local player: they move
+// vertically and start centered on their wall. The paddles of the top and bottom walls
+// are the same paddles turned sideways, moving left on the up input and right on the
+// down input. Paddles are kept within the field borders.
+//
+// The length of a paddle along its wall can be scaled, e.g. by power-ups. The speed is
+// the distance the paddle moves on each input.
+type Paddle struct {
+	name     string
+	side     geometry.Side
+	field    *Field
+	position geometry.Vector
+	length   float64
+	speed    float64
+}
+
+var _ player.Player = (*Paddle)(nil)
+
+func NewPaddle(name string, side geometry.Side, field *Field, speed float64) *Paddle {
+	paddle := &Paddle{
+		name:   name,
+		side:   side,
+		field:  field,
+		length: paddleLength,
+		speed:  speed,
+	}
+	paddle.center()
+
+	return paddle
+}
+
+func (p *Paddle) center() {
+	switch p.side {
+	case geometry.Left:
+		p.position = geometry.Vector{X: paddleDistance, Y: (p.field.Height - p.length) / 2}
+	case geometry.Right:
+		p.position = geometry.Vector{X: p.field.Width - paddleDistance - paddleWidth, Y: (p.field.Height - p.length) / 2}
+	case Top:
+		p.position = geometry.Vector{X: (p.field.Width - p.length) / 2, Y: paddleDistance}
+	case Bottom:
+		p.position = geometry.Vector{X: (p.field.Width - p.length) / 2, Y: p.field.Height - paddleDistance - paddleWidth}
+	}
+}
+
+// BouncerHeight returns the height of the paddle, its length along its wall
+func (p *Paddle) BouncerHeight() float64 {
+	return p.length
+}
+
+// BouncerWidth returns the width of the paddle, its thickness
+func (p *Paddle) BouncerWidth() float64 {
+	return paddleWidth
+}
+
+// Bounds returns the rectangle covered by the paddle on the field
+func (p *Paddle) Bounds() geometry.Rect {
+	if p.horizontal() {
+		return geometry.Rect{X: p.position.X, Y: p.position.Y, Width: p.length, Height: paddleWidth}
+	}
+
+	return geometry.Rect{X: p.position.X, Y: p.position.Y, Width: paddleWidth, Height: p.length}
+}
+
+// Name returns the name of the player controlling the paddle
+func (p *Paddle) Name() string {
+	return p.name
+}
+
+// Side returns the wall defended by the paddle
+func (p *Paddle) Side() geometry.Side {
+	return p.side
+}
+
+// Position returns the top left corner of the paddle
+func (p *Paddle) Position() geometry.Vector {
+	return p.position
+}
+
+// Reset centers the paddle on its wall
+func (p *Paddle) Reset() {
+	p.center()
+}
+
+// Scale sets the length of the paddle to the given factor of the regular length,
+// keeping the paddle centered on the same point of its wall
+func (p *Paddle) Scale(factor float64) {
+	middle := p.along() + p.length/2
+	p.length = paddleLength * factor
+
+	if p.horizontal() {
+		p.position.X = p.keepInBounds(middle-p.length/2, p.field.Width)
+		return
+	}
+
+	p.position.Y = p.keepInBounds(middle-p.length/2, p.field.Height)
+}
+
+// SetPosition moves the paddle along its wall, to the given Y for vertical paddles and X for horizontal ones
+func (p *Paddle) SetPosition(position float64) {
+	if p.horizontal() {
+		p.position.X = position
+		return
+	}
+
+	p.position.Y = position
+}
+
+// Update moves the paddle along its wall following the player's input
+func (p *Paddle) Update(input player.Input) {
+	offset := 0.0
+	switch {
+	case input.Up:
+		offset = -p.speed
+	case input.Down:
+		offset = p.speed
+	}
+
+	if p.horizontal() {
+		p.position.X = p.keepInBounds(p.position.X+offset, p.field.Width)
+		return
+	}
+
+	p.position.Y = p.keepInBounds(p.position.Y+offset, p.field.Height)
+}
+
+func (p *Paddle) keepInBounds(position float64, length float64) float64 {
+	return min(max(position, p.field.Border), length-p.length-p.field.Border)
+}
+
+// along returns the position of the paddle along its wall
+func (p *Paddle) along() float64 {
+	if p.horizontal() {
+		return p.position.X
+	}
+
+	return p.position.Y
+}
+
+func (p *Paddle) horizontal() bool {
+	return p.side == Top || p.side == Bottom
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/events"
+)
+
+const failedDir = "failed"
+
+// Delivery is a single event to be posted to a single webhook URL
+type Delivery struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	EventType events.Type     `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Outbox holds the pending deliveries of every URL in order, persisting them so they
+// survive server restarts
+//
+// Each delivery is stored in its own file, named after its creation time so the
+// pending deliveries are loaded back in order. Deliveries that exhausted their
+// attempts are moved to the failed directory for inspection. An Outbox without a
+// directory keeps the deliveries in memory only, and they're lost on restarts.
+type Outbox struct {
+	sync.Mutex
+	dir     string
+	pending map[string][]Delivery
+}
+
+// NewOutbox creates the outbox, loading the deliveries left pending by previous runs
+func NewOutbox(dir string) (*Outbox, error) {
+	outbox := &Outbox{dir: dir, pending: make(map[string][]Delivery)}
+	if dir == "" {
+		return outbox, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, failedDir), 0o755); err != nil {
+		return nil, fmt.Errorf("creating webhook outbox: %w", err)
+	}
+
+	deliveries, err := outbox.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delivery := range deliveries {
+		outbox.pending[delivery.URL] = append(outbox.pending[delivery.URL], delivery)
+	}
+
+	return outbox, nil
+}
+
+// Put adds a pending delivery after the others of its URL, or updates it when it's already pending
+//
+// The delivery is only handed to the workers once it's persisted, so it can't succeed
+// before its file exists. It stays pending when it can't be persisted.
+func (o *Outbox) Put(delivery Delivery) error {
+	err := o.store(delivery)
+
+	o.Lock()
+	defer o.Unlock()
+
+	queue := o.pending[delivery.URL]
+	if i := slices.IndexFunc(queue, sameDelivery(delivery)); i >= 0 {
+		queue[i] = delivery
+	} else {
+		o.pending[delivery.URL] = append(queue, delivery)
+	}
+
+	return err
+}
+
+// store writes the delivery file, replacing it atomically
+func (o *Outbox) store(delivery Delivery) error {
+	if o.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("encoding delivery: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(o.dir, ".*.tmp")
+	if err != nil {
+		return fmt.Errorf("storing delivery: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("storing delivery: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("storing delivery: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), o.path(delivery)); err != nil {
+		return fmt.Errorf("storing delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Next returns the oldest pending delivery of the URL
+func (o *Outbox) Next(url string) (Delivery, bool) {
+	o.Lock()
+	defer o.Unlock()
+
+	if len(o.pending[url]) == 0 {
+		return Delivery{}, false
+	}
+
+	return o.pending[url][0], true
+}
+
+// URLs returns the URLs with pending deliveries
+func (o *Outbox) URLs() []string {
+	o.Lock()
+	defer o.Unlock()
+
+	urls := make([]string, 0, len(o.pending))
+	for url, queue := range o.pending {
+		if len(queue) > 0 {
+			urls = append(urls, url)
+		}
+	}
+	sort.Strings(urls)
+
+	return urls
+}
+
+// Remove deletes a delivery that succeeded
+func (o *Outbox) Remove(delivery Delivery) error {
+	o.drop(delivery)
+
+	if o.dir == "" {
+		return nil
+	}
+
+	if err := os.Remove(o.path(delivery)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Fail moves a delivery that exhausted its attempts to the failed directory
+func (o *Outbox) Fail(delivery Delivery) error {
+	o.drop(delivery)
+
+	if o.dir == "" {
+		return nil
+	}
+
+	if err := os.Rename(o.path(delivery), filepath.Join(o.dir, failedDir, fileName(delivery))); err != nil {
+		return fmt.Errorf("moving failed delivery: %w", err)
+	}
+
+	return nil
+}
+
+// drop takes the delivery out of the pending ones of its URL
+func (o *Outbox) drop(delivery Delivery) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.pending[delivery.URL] = slices.DeleteFunc(o.pending[delivery.URL], sameDelivery(delivery))
+	if len(o.pending[delivery.URL]) == 0 {
+		delete(o.pending, delivery.URL)
+	}
+}
+
+func sameDelivery(delivery Delivery) func(Delivery) bool {
+	return func(pending Delivery) bool {
+		return pending.ID == delivery.ID
+	}
+}
+
+// load reads the stored deliveries in creation order
+func (o *Outbox) load() ([]Delivery, error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading webhook outbox: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	deliveries := make([]Delivery, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(o.dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("reading delivery: %w", err)
+		}
+
+		var delivery Delivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return nil, fmt.Errorf("parsing delivery %s: %w", name, err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (o *Outbox) path(delivery Delivery) string {
+	return filepath.Join(o.dir, fileName(delivery))
+}
+
+func fileName(delivery Delivery) string {
+	return fmt.Sprintf("%020d-%s.json", delivery.CreatedAt.UnixNano(), delivery.ID)
+}
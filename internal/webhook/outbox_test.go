@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOutboxOrder(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	deliveries := []Delivery{
+		{ID: "a1", URL: "http://a.example", CreatedAt: start},
+		{ID: "b1", URL: "http://b.example", CreatedAt: start.Add(time.Second)},
+		{ID: "a2", URL: "http://a.example", CreatedAt: start.Add(2 * time.Second)},
+		{ID: "a3", URL: "http://a.example", CreatedAt: start.Add(3 * time.Second)},
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		reload bool
+	}{
+		{"in memory", "", false},
+		{"persisted", t.TempDir(), false},
+		{"reloaded", t.TempDir(), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outbox, err := NewOutbox(test.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, delivery := range deliveries {
+				if err := outbox.Put(delivery); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Retrying a delivery updates it in place
+			retried := deliveries[0]
+			retried.Attempts = 1
+			if err := outbox.Put(retried); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.reload {
+				if outbox, err = NewOutbox(test.dir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []string
+			for delivery, ok := outbox.Next("http://a.example"); ok; delivery, ok = outbox.Next("http://a.example") {
+				if delivery.ID == "a1" && delivery.Attempts != 1 {
+					t.Errorf("delivery a1 has %d attempts, want 1", delivery.Attempts)
+				}
+
+				got = append(got, delivery.ID)
+
+				done := outbox.Remove
+				if delivery.ID == "a2" {
+					done = outbox.Fail
+				}
+
+				if err := done(delivery); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if want := []string{"a1", "a2", "a3"}; !slices.Equal(got, want) {
+				t.Errorf("deliveries of a.example = %v, want %v", got, want)
+			}
+
+			if urls := outbox.URLs(); len(urls) != 1 || urls[0] != "http://b.example" {
+				t.Errorf("URLs() = %v, want [http://b.example]", urls)
+			}
+
+			if test.dir == "" {
+				return
+			}
+
+			if pending := files(t, test.dir); pending != 1 {
+				t.Errorf("outbox has %d deliveries, want 1", pending)
+			}
+
+			if failed := files(t, filepath.Join(test.dir, failedDir)); failed != 1 {
+				t.Errorf("failed directory has %d deliveries, want 1", failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/events"
+)
+
+const maxBackoff = time.Minute
+
+// Sink delivers the server events to the configured webhook URLs
+//
+// Each event is stored in the outbox, and removed once the receiver acknowledges it
+// with a 2xx response. Every URL has its own worker posting the URL's pending
+// deliveries from the outbox in order, retrying the failed requests with exponential
+// backoff. A failing receiver only delays its own deliveries, and storing the events
+// never waits for the workers, so the event bus isn't held up either.
+//
+// The requests are signed with HMAC-SHA256 over the timestamp and the body:
+//
+//	X-Pongo-Signature: sha256=hex(hmac(secret, timestamp + "." + body))
+type Sink struct {
+	secret      []byte
+	maxAttempts int
+	filter      map[events.Type]bool
+	client      *http.Client
+	outbox      *Outbox
+	targets     map[string]chan struct{}
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func New(cfg config.Webhooks) (*Sink, error) {
+	outbox, err := NewOutbox(cfg.OutboxDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sink := &Sink{
+		secret:      []byte(cfg.Secret),
+		maxAttempts: cfg.MaxAttempts,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		outbox:      outbox,
+		targets:     make(map[string]chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	if len(cfg.Events) > 0 {
+		sink.filter = make(map[events.Type]bool)
+		for _, eventType := range cfg.Events {
+			sink.filter[events.Type(eventType)] = true
+		}
+	}
+
+	for _, url := range cfg.URLs {
+		sink.targets[url] = make(chan struct{}, 1)
+	}
+
+	return sink, nil
+}
+
+// Start begins delivering, resuming with the deliveries left in the outbox by previous runs
+//
+// The deliveries to URLs no longer configured are moved to the failed ones.
+func (s *Sink) Start() error {
+	for _, url := range s.outbox.URLs() {
+		if _, ok := s.targets[url]; ok {
+			continue
+		}
+
+		slog.Warn("Webhook URL no longer configured, moving its deliveries to failed", slog.String("url", url))
+		for delivery, ok := s.outbox.Next(url); ok; delivery, ok = s.outbox.Next(url) {
+			if err := s.outbox.Fail(delivery); err != nil {
+				slog.Error("Error moving webhook delivery", slog.Any("error", err))
+			}
+		}
+	}
+
+	for url, wake := range s.targets {
+		s.wg.Add(1)
+		go s.run(url, wake)
+
+		slog.Info("Webhook delivery started", slog.String("url", url))
+	}
+
+	return nil
+}
+
+// Handle stores the event in the outbox for every URL and wakes up their workers, without waiting for them
+//
+// It's meant to be subscribed to the event bus.
+func (s *Sink) Handle(event events.Event) {
+	if s.filter != nil && !s.filter[event.Type] {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Error encoding webhook event", slog.Any("error", err), slog.Any("type", event.Type))
+		return
+	}
+
+	for url := range s.targets {
+		delivery := Delivery{
+			ID:        uuid.NewString(),
+			URL:       url,
+			EventType: event.Type,
+			Payload:   payload,
+			CreatedAt: time.Now(),
+		}
+
+		if err := s.outbox.Put(delivery); err != nil {
+			slog.Error("Error storing webhook delivery", slog.Any("error", err))
+		}
+	}
+
+	for _, wake := range s.targets {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close stops the deliveries, keeping the pending ones in the outbox for the next run
+func (s *Sink) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// run posts the pending deliveries of the URL in order, waiting to be woken up when there are none
+func (s *Sink) run(url string, wake chan struct{}) {
+	defer s.wg.Done()
+
+	for {
+		for delivery, ok := s.outbox.Next(url); ok && s.ctx.Err() == nil; delivery, ok = s.outbox.Next(url) {
+			s.deliver(delivery)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-wake:
+		}
+	}
+}
+
+// deliver posts the delivery until it succeeds, its attempts are exhausted or the sink is closed
+func (s *Sink) deliver(delivery Delivery) {
+	for {
+		err := s.post(delivery)
+		if err == nil {
+			if err := s.outbox.Remove(delivery); err != nil {
+				slog.Error("Error removing webhook delivery", slog.Any("error", err))
+			}
+			return
+		}
+
+		delivery.Attempts++
+
+		if delivery.Attempts >= s.maxAttempts {
+			slog.Error("Webhook delivery failed, giving up", slog.Any("error", err), slog.String("url", delivery.URL), slog.String("id", delivery.ID), slog.Int("attempts", delivery.Attempts))
+			if err := s.outbox.Fail(delivery); err != nil {
+				slog.Error("Error moving webhook delivery", slog.Any("error", err))
+			}
+			return
+		}
+
+		slog.Warn("Webhook delivery failed, retrying", slog.Any("error", err), slog.String("url", delivery.URL), slog.String("id", delivery.ID), slog.Int("attempts", delivery.Attempts))
+
+		if err := s.outbox.Put(delivery); err != nil {
+			slog.Error("Error storing webhook delivery", slog.Any("error", err))
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(backoff(delivery.Attempts)):
+		}
+	}
+}
+
+func (s *Sink) post(delivery Delivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request, err := http.NewRequestWithContext(s.ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "pongo-server-webhook")
+	request.Header.Set("X-Pongo-Event", string(delivery.EventType))
+	request.Header.Set("X-Pongo-Delivery", delivery.ID)
+	request.Header.Set("X-Pongo-Timestamp", timestamp)
+	request.Header.Set("X-Pongo-Signature", "sha256="+Sign(s.secret, timestamp, delivery.Payload))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign computes the hex encoded signature of a webhook request, allowing receivers to verify it
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func backoff(attempts int) time.Duration {
+	if attempts > 6 {
+		return maxBackoff
+	}
+
+	return min(time.Second<<(attempts-1), maxBackoff)
+}
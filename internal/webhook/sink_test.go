@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/events"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"known signature", "secret", "1700000000", `{"type":"session.started"}`, "f028b07bd235e2b51cd5e7cc2ac755265288202a3306f7826729c5ceaa63a5fb"},
+		{"other secret", "other", "1700000000", `{"type":"session.started"}`, "94f99b4f254d1b119ff7fc15f7dde6dcf83a3ff1ee0cb32af12fbc020d8ac6f4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Sign([]byte(test.secret), test.timestamp, []byte(test.body)); got != test.want {
+				t.Errorf("Sign() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, maxBackoff},
+		{20, maxBackoff},
+	}
+
+	for _, test := range tests {
+		if got := backoff(test.attempts); got != test.want {
+			t.Errorf("backoff(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestSinkDelivery(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		statuses    []int
+		wantFailed  int
+	}{
+		{"acknowledged", 3, []int{http.StatusOK}, 0},
+		{"retried until acknowledged", 3, []int{http.StatusInternalServerError, http.StatusNoContent}, 0},
+		{"given up", 2, []int{http.StatusInternalServerError, http.StatusBadGateway}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mutex sync.Mutex
+			requests := 0
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if r.Header.Get("X-Pongo-Signature") == "" || r.Header.Get("X-Pongo-Event") != string(events.PlayerJoined) {
+					t.Errorf("request without the webhook headers: %v", r.Header)
+				}
+
+				w.WriteHeader(test.statuses[min(requests, len(test.statuses)-1)])
+				requests++
+			}))
+			defer receiver.Close()
+
+			dir := t.TempDir()
+			sink, err := New(config.Webhooks{
+				URLs:        []string{receiver.URL},
+				Secret:      "secret",
+				MaxAttempts: test.maxAttempts,
+				Timeout:     time.Second,
+				OutboxDir:   dir,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := sink.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+
+			sink.Handle(events.New(events.PlayerJoined, "session", nil))
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(sink.outbox.URLs()) > 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("delivery still pending")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			mutex.Lock()
+			if requests != len(test.statuses) {
+				t.Errorf("receiver got %d requests, want %d", requests, len(test.statuses))
+			}
+			mutex.Unlock()
+
+			if pending := files(t, dir); pending != 0 {
+				t.Errorf("outbox has %d deliveries, want 0", pending)
+			}
+
+			if failed := files(t, filepath.Join(dir, failedDir)); failed != test.wantFailed {
+				t.Errorf("failed directory has %d deliveries, want %d", failed, test.wantFailed)
+			}
+		})
+	}
+}
+
+// files counts the delivery files of a directory
+func files(t *testing.T, dir string) int {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(matches)
+}
+
+func TestSinkFailsUnconfiguredURLs(t *testing.T) {
+	dir := t.TempDir()
+
+	outbox, err := NewOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outbox.Put(Delivery{ID: "1", URL: "http://removed.example", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	sink, err := New(config.Webhooks{URLs: []string{"http://kept.example"}, MaxAttempts: 1, Timeout: time.Second, OutboxDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sink.Start(); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	if failed := files(t, filepath.Join(dir, failedDir)); failed != 1 {
+		t.Errorf("failed directory has %d deliveries, want 1", failed)
+	}
+}
@@ -0,0 +1,70 @@
+package ws
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/events"
+	"github.com/reneepc/pongo-server/internal/game"
+)
+
+// admit decides whether a new connection from the request's client is accepted
+//
+// Banned IPs are refused, as well as clients exceeding the connection rate or
+// the number of concurrent connections allowed by the given limiter. On success,
+// the client IP is returned and the limiter slot must be released by the caller.
+func (s *Server) admit(w http.ResponseWriter, r *http.Request, limiter *access.ConnectionLimiter) (string, bool) {
+	ip := s.Proxies.ClientIP(r)
+
+	if s.Bans.IsBanned(access.BanIP, ip) {
+		slog.Warn("Refused connection from banned IP", slog.String("ip", ip), slog.String("path", r.URL.Path))
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return "", false
+	}
+
+	if !s.rateLimiter.Allow(ip) {
+		slog.Warn("Connection rate limit exceeded", slog.String("ip", ip), slog.String("path", r.URL.Path))
+		http.Error(w, "Too many connection attempts", http.StatusTooManyRequests)
+		return "", false
+	}
+
+	if !limiter.Acquire(ip) {
+		slog.Warn("Concurrent connection limit exceeded", slog.String("ip", ip), slog.String("path", r.URL.Path))
+		http.Error(w, "Too many connections", http.StatusTooManyRequests)
+		return "", false
+	}
+
+	return ip, true
+}
+
+// releaseOnDisconnect frees the connection slot of a client once its connection is terminated
+func releaseOnDisconnect(network *game.Network, limiter *access.ConnectionLimiter, ip string) {
+	<-network.Ctx.Done()
+	limiter.Release(ip)
+}
+
+// leaveQueueOnDisconnect removes the player from the match queue once its connection is terminated
+func (s *Server) leaveQueueOnDisconnect(player *game.Network) {
+	<-player.Ctx.Done()
+	s.PlayerPool.RemovePlayer(player)
+}
+
+// notifyPlayerLeft publishes the player leave event once the player's connection is terminated
+func (s *Server) notifyPlayerLeft(player *game.Network) {
+	<-player.Ctx.Done()
+	s.events.Publish(events.New(events.PlayerLeft, "", game.PlayerEvent{Player: player.Details()}))
+}
+
+// closeReason capitalizes an error message to be sent as a close reason
+func closeReason(err error) string {
+	message := err.Error()
+
+	return strings.ToUpper(message[:1]) + message[1:]
+}
+
+// isBannedPlayer checks the player's name and account against the ban list
+func (s *Server) isBannedPlayer(info game.GameInfo) bool {
+	return s.Bans.IsBanned(access.BanName, info.PlayerName) || s.Bans.IsBanned(access.BanAccount, info.AccountID)
+}
@@ -6,61 +6,134 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/reneepc/pongo-server/internal/access"
+	"github.com/reneepc/pongo-server/internal/config"
+	"github.com/reneepc/pongo-server/internal/events"
 	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/lobby"
 	"github.com/reneepc/pongo-server/internal/matchmaking"
+	"github.com/reneepc/pongo-server/internal/physics"
 )
 
 // Server is the WebSocket server
 //
 // It is responsible for handling incoming connections and managing the player pool.
+// Connections are guarded by the origins allowlist, the ban list, a per-IP connection
+// rate limiter and limits on the number of concurrent players and spectators. Players
+// queuing for a field layout missing from the layouts are refused.
 type Server struct {
-	PlayerPool *matchmaking.PlayerPool
-	upgrader   websocket.Upgrader
+	PlayerPool       *matchmaking.PlayerPool
+	Sessions         *game.SessionManager
+	Bans             *access.BanList
+	Origins          *access.Origins
+	Proxies          *access.Proxies
+	Lobby            *lobby.Lobby
+	Layouts          physics.Layouts
+	upgrader         websocket.Upgrader
+	rateLimiter      *access.RateLimiter
+	playerLimiter    *access.ConnectionLimiter
+	spectatorLimiter *access.ConnectionLimiter
+	handshakeTimeout time.Duration
+	pingInterval     time.Duration
+	events           *events.Bus
 }
 
-func New() *Server {
+// New creates the WebSocket server, which adds players to the given pool and
+// spectators to the sessions of the given session manager
+//
+// The client IPs are read from the X-Forwarded-For header of the given trusted proxies.
+// Lobby clients are joined to the given lobby, and players joining and leaving the
+// server are published to the given event bus.
+func New(cfg config.WebSocket, origins *access.Origins, proxies *access.Proxies, bans *access.BanList, pool *matchmaking.PlayerPool, sessions *game.SessionManager, lobby *lobby.Lobby, layouts physics.Layouts, bus *events.Bus) *Server {
 	return &Server{
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
+			CheckOrigin: origins.CheckOrigin,
 		},
-		PlayerPool: matchmaking.NewPlayerPool(),
+		PlayerPool:       pool,
+		Sessions:         sessions,
+		Bans:             bans,
+		Origins:          origins,
+		Proxies:          proxies,
+		Lobby:            lobby,
+		Layouts:          layouts,
+		rateLimiter:      access.NewRateLimiter(cfg.ConnectionRate, cfg.ConnectionBurst),
+		playerLimiter:    access.NewConnectionLimiter(cfg.MaxPlayersPerIP, cfg.MaxPlayers),
+		spectatorLimiter: access.NewConnectionLimiter(cfg.MaxSpectatorsPerIP, cfg.MaxSpectators),
+		handshakeTimeout: cfg.HandshakeTimeout,
+		pingInterval:     cfg.PingInterval,
+		events:           bus,
 	}
 }
 
 func (s *Server) HandleConnections(w http.ResponseWriter, r *http.Request) {
-	// CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	s.Origins.SetCORSHeaders(w, r)
+
+	ip, ok := s.admit(w, r, s.playerLimiter)
+	if !ok {
+		return
+	}
 
 	// Upgrade HTTP connection to WebSocket
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		slog.Error("Failed to upgrade connection", slog.Any("error", err))
+		s.playerLimiter.Release(ip)
 		return
 	}
 
-	// Wait for initial player info
+	// Wait for initial player info, without holding the connection forever
+	conn.SetReadDeadline(time.Now().Add(s.handshakeTimeout))
+
 	var info game.GameInfo
 	if err := conn.ReadJSON(&info); err != nil {
 		err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Failed to read player info"), time.Now().Add(time.Second))
 		if err != nil {
 			slog.Error("Failed to write close message after reading wrongly formatted player info", slog.Any("error", err))
 		}
-		slog.Error("Failed to read player info", slog.Any("error", err))
+		slog.Error("Failed to read player info", slog.Any("error", err), slog.String("ip", ip))
+		conn.Close()
+		s.playerLimiter.Release(ip)
 		return
 	}
 
-	slog.Info("New player connected", slog.String("name", info.PlayerName))
+	conn.SetReadDeadline(time.Time{})
 
 	newPlayer := game.NewNetwork(conn, info)
+	newPlayer.IP = ip
+	go releaseOnDisconnect(newPlayer, s.playerLimiter, ip)
+
+	if err := info.Mode.Validate(); err != nil {
+		slog.Warn("Refused player with an unknown game mode", slog.String("name", info.PlayerName), slog.Any("mode", info.Mode))
+		newPlayer.Close(closeReason(err))
+		return
+	}
+
+	if _, err := s.Layouts.Get(info.Layout); err != nil {
+		slog.Warn("Refused player with an unknown field layout", slog.String("name", info.PlayerName), slog.String("layout", info.Layout))
+		newPlayer.Close(closeReason(err))
+		return
+	}
+
+	if s.isBannedPlayer(info) {
+		slog.Warn("Refused banned player", slog.String("name", info.PlayerName), slog.String("ip", ip))
+		newPlayer.Close("Banned")
+		return
+	}
+
+	slog.Info("New player connected", slog.String("name", info.PlayerName), slog.String("ip", ip))
+
+	s.events.Publish(events.New(events.PlayerJoined, "", game.PlayerEvent{Player: newPlayer.Details()}))
+	go s.notifyPlayerLeft(newPlayer)
 
 	// Starts ping measurement
 	s.measureLatency(newPlayer)
 
 	// Setup connection close handler and context cancellation on disconnect
 	s.handleClosedConnection(newPlayer)
+	go s.leaveQueueOnDisconnect(newPlayer)
+
+	// Read the player messages, which are handled by the queue and later by the session
+	go newPlayer.ReadMessages()
 
 	s.PlayerPool.AddPlayer(newPlayer)
 }
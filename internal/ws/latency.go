@@ -12,17 +12,17 @@ func (s Server) measureLatency(player *game.Network) {
 	handlePong(player)
 
 	// Send periodically ping messages to the player's client.
-	go sendPingMessages(player)
+	go sendPingMessages(player, s.pingInterval)
 }
 
-func sendPingMessages(player *game.Network) {
+func sendPingMessages(player *game.Network, interval time.Duration) {
 	for {
 		select {
 		case <-player.Ctx.Done():
 			slog.Info("Stopping ping messages", slog.String("name", player.GameInfo.PlayerName))
 			return
 
-		case <-time.After(5 * time.Second):
+		case <-time.After(interval):
 			player.Ping()
 		}
 	}
@@ -0,0 +1,51 @@
+package ws
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/lobby"
+)
+
+// HandleLobbyConnections handles incoming lobby connections
+//
+// Lobby clients don't send any message: they receive the list of sessions and the size
+// of the match queue once connected, followed by their changes. Lobby connections count
+// towards the spectator connection limits.
+func (s *Server) HandleLobbyConnections(w http.ResponseWriter, r *http.Request) {
+	s.Origins.SetCORSHeaders(w, r)
+
+	ip, ok := s.admit(w, r, s.spectatorLimiter)
+	if !ok {
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade lobby connection", slog.Any("error", err))
+		s.spectatorLimiter.Release(ip)
+		return
+	}
+
+	client := game.NewNetwork(conn, game.GameInfo{})
+	go releaseOnDisconnect(client, s.spectatorLimiter, ip)
+
+	s.Lobby.Join(client)
+
+	slog.Info("Lobby client connected", slog.String("ip", ip))
+
+	go readLobbyMessages(client, s.Lobby)
+}
+
+// readLobbyMessages keeps reading the lobby connection to process control messages
+// and to detect closed connections, discarding anything the client sends
+func readLobbyMessages(client *game.Network, lobby *lobby.Lobby) {
+	for {
+		if _, _, err := client.Conn.NextReader(); err != nil {
+			lobby.Leave(client)
+			client.Terminate()
+			return
+		}
+	}
+}
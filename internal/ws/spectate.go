@@ -9,8 +9,13 @@ import (
 	"github.com/reneepc/pongo-server/internal/game"
 )
 
+// SpectateRequest is the first message sent by spectators, with the session to watch and how to watch it
+//
+// The name is optional, and only required to use the spectators chat.
 type SpectateRequest struct {
 	SessionID string `json:"session_id"`
+	Name      string `json:"name,omitempty"`
+	game.SpectateOptions
 }
 
 // HandleSpectatorConnections handles incoming spectator connections for a given session ID
@@ -21,39 +26,69 @@ type SpectateRequest struct {
 // The spectator is added to the session, and a close handler is set to remove the spectator
 // from the session when the connection is closed.
 func (s *Server) HandleSpectatorConnections(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	s.Origins.SetCORSHeaders(w, r)
+
+	ip, ok := s.admit(w, r, s.spectatorLimiter)
+	if !ok {
+		return
+	}
 
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		slog.Error("Failed to upgrade spectator connection", slog.Any("error", err))
+		s.spectatorLimiter.Release(ip)
 		return
 	}
 
+	conn.SetReadDeadline(time.Now().Add(s.handshakeTimeout))
+
 	var spectateRequest SpectateRequest
 	if err := conn.ReadJSON(&spectateRequest); err != nil {
 		err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Failed to read spectate request"), time.Now().Add(time.Second))
 		if err != nil {
 			slog.Error("Failed to write close message after reading wrongly formatted spectate request", slog.Any("error", err))
 		}
-		slog.Error("Failed to read spectate request", slog.Any("error", err))
+		slog.Error("Failed to read spectate request", slog.Any("error", err), slog.String("ip", ip))
+		conn.Close()
+		s.spectatorLimiter.Release(ip)
 		return
 	}
 
-	session := game.GetSessionManager().Session(spectateRequest.SessionID)
+	conn.SetReadDeadline(time.Time{})
+
+	spectator := game.NewNetwork(conn, game.GameInfo{PlayerName: spectateRequest.Name})
+	spectator.IP = ip
+	go releaseOnDisconnect(spectator, s.spectatorLimiter, ip)
+
+	if s.isBannedPlayer(spectator.GameInfo) {
+		slog.Warn("Refused banned spectator", slog.String("name", spectateRequest.Name), slog.String("ip", ip))
+		spectator.Close("Banned")
+		return
+	}
+
+	session := s.Sessions.Session(spectateRequest.SessionID)
 	if session == nil {
-		err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Session not found"), time.Now().Add(time.Second))
-		if err != nil {
-			slog.Error("Failed to write close message after session not found", slog.Any("error", err))
-		}
 		slog.Error("Session not found", slog.String("session_id", spectateRequest.SessionID))
+		spectator.Close("Session not found")
 		return
 	}
 
-	spectator := game.NewNetwork(conn, game.GameInfo{})
-	session.AddSpectator(spectator)
+	if err := spectateRequest.Validate(); err != nil {
+		slog.Warn("Invalid spectate options", slog.Any("perspective", spectateRequest.Perspective), slog.Int("rate", spectateRequest.Rate))
+		spectator.Close("Invalid spectate options")
+		return
+	}
+
+	if err := session.AddSpectator(spectator, spectateRequest.SpectateOptions); err != nil {
+		slog.Warn("Refused spectator", slog.Any("error", err), slog.String("session_id", session.ID), slog.String("ip", ip))
+		spectator.Close(closeReason(err))
+		return
+	}
 
 	s.handleSpectatorDisconnection(spectator, session)
+
+	go leaveSessionOnDisconnect(spectator, session)
+	go spectator.ReadMessages()
 }
 
 func (s *Server) handleSpectatorDisconnection(spectator *game.Network, session *game.GameSession) {
@@ -64,3 +99,11 @@ func (s *Server) handleSpectatorDisconnection(spectator *game.Network, session *
 		return nil
 	})
 }
+
+// leaveSessionOnDisconnect removes the spectator from the session once its connection is terminated
+//
+// Reading the spectator messages detects the connections dropped without a close message.
+func leaveSessionOnDisconnect(spectator *game.Network, session *game.GameSession) {
+	<-spectator.Ctx.Done()
+	session.RemoveSpectator(spectator)
+}
@@ -2,35 +2,41 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"flag"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/reneepc/pongo-server/internal/httpserver"
-	"github.com/reneepc/pongo-server/internal/ws"
+	"github.com/reneepc/pongo-server/pkg/pongoserver"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	cfg, err := pongoserver.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		slog.Error("Error loading configuration", slog.Any("error", err))
+		os.Exit(2)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, nil)))
-
-	slog.Info("Starting Pong Multiplayer Server", slog.String("port", port))
+	slog.Info("Starting Pong Multiplayer Server", slog.String("port", cfg.HTTP.Port))
 
-	httpServer := httpserver.New()
-	wsServer := ws.New()
+	server, err := pongoserver.New(pongoserver.WithConfig(cfg))
+	if err != nil {
+		slog.Error("Error creating server", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	go func() {
-		host := fmt.Sprintf("0.0.0.0:%s", port)
-		err := httpServer.Start(host, wsServer)
-		if err != nil {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("Error starting server", slog.Any("error", err))
 			cancel()
 
@@ -38,10 +44,10 @@ func main() {
 		}
 	}()
 
-	shutdown(ctx, httpServer, wsServer)
+	shutdown(ctx, server)
 }
 
-func shutdown(ctx context.Context, s *httpserver.Server, wsServer *ws.Server) {
+func shutdown(ctx context.Context, server *pongoserver.Server) {
 	quitSignal := make(chan os.Signal, 1)
 	signal.Notify(quitSignal, os.Interrupt, syscall.SIGTERM)
 
@@ -51,11 +57,8 @@ func shutdown(ctx context.Context, s *httpserver.Server, wsServer *ws.Server) {
 	}
 
 	slog.Info("Shutting down server")
-	for _, player := range wsServer.PlayerPool.Players {
-		player.Terminate()
-	}
 
-	if err := s.Shutdown(); err != nil {
+	if err := server.Shutdown(); err != nil {
 		slog.Error("Error shutting down server", slog.Any("error", err))
 		os.Exit(1)
 	}
@@ -0,0 +1,26 @@
+package pongoserver
+
+import "github.com/reneepc/pongo-server/internal/config"
+
+// Config is the complete server configuration
+type Config = config.Config
+
+// HTTPConfig configures the HTTP server and its routes
+type HTTPConfig = config.HTTP
+
+// WebSocketConfig configures the players and spectators connections
+type WebSocketConfig = config.WebSocket
+
+// GameConfig configures the game sessions
+type GameConfig = config.Game
+
+// DefaultConfig returns the configuration used when no setting is given
+func DefaultConfig() Config {
+	return config.Default()
+}
+
+// LoadConfig builds the configuration from the defaults, a YAML file, the environment
+// variables and the command line arguments, in this order of precedence
+func LoadConfig(args []string) (Config, error) {
+	return config.Load(args)
+}
@@ -0,0 +1,249 @@
+package pongoserver
+
+import (
+	"time"
+
+	"github.com/gandarez/pong-multiplayer-go/pkg/geometry"
+	"github.com/reneepc/pongo-server/internal/events"
+	"github.com/reneepc/pongo-server/internal/game"
+	"github.com/reneepc/pongo-server/internal/physics"
+)
+
+// Side is the side of the field defended by a player
+type Side string
+
+const (
+	SideNone   Side = ""
+	SideLeft   Side = "left"
+	SideRight  Side = "right"
+	SideTop    Side = "top"
+	SideBottom Side = "bottom"
+)
+
+// Player identifies a connected player
+//
+// The ID is unique per connection. The Side is only set once the player is in a match.
+type Player struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	AccountID string `json:"account_id,omitempty"`
+	Side      Side   `json:"side,omitempty"`
+}
+
+// Hooks are callbacks notified of the server game events
+//
+// They are called from a dedicated goroutine, in the order the events happened.
+// Events are dropped for hooks that fall too far behind, so they should return
+// quickly. Nil callbacks are ignored.
+type Hooks struct {
+	OnPlayerJoin  func(event PlayerEvent)
+	OnPlayerLeave func(event PlayerEvent)
+	OnMatchStart  func(event MatchStartEvent)
+	OnGoal        func(event GoalEvent)
+	OnMatchEnd    func(result MatchResult)
+}
+
+// PlayerEvent is sent when a player connects to the server or leaves it
+type PlayerEvent struct {
+	Player Player    `json:"player"`
+	Time   time.Time `json:"time"`
+}
+
+// MatchStartEvent is sent when two players are matched and their match starts
+type MatchStartEvent struct {
+	SessionID string    `json:"session_id"`
+	Players   []Player  `json:"players"`
+	Time      time.Time `json:"time"`
+}
+
+// GoalEvent is sent when a player scores
+//
+// The Conceder is the player the goal was scored against, the first of its side in team
+// modes. The Scorer is nil for arena goals conceded before any other player hit the ball.
+// The Ball identifies the ball that scored within the match, as several balls can be in play.
+type GoalEvent struct {
+	SessionID string        `json:"session_id"`
+	Ball      int           `json:"ball"`
+	Scorer    *Player       `json:"scorer,omitempty"`
+	Conceder  Player        `json:"conceder"`
+	Scores    []PlayerScore `json:"scores"`
+	Time      time.Time     `json:"time"`
+}
+
+// PlayerScore is the score of a player in a match
+type PlayerScore struct {
+	Player Player `json:"player"`
+	Score  int    `json:"score"`
+}
+
+// MatchResult is the final outcome of a match
+//
+// The Winners are the players of the winning side, and the Winner is the first of them.
+// Both are empty when the match ended without a winner. A player that disconnects
+// forfeits the match for its whole side. The Mode is "classic", "doubles",
+// "doubles_open", "arena" or "multiball". The Reason is one of "score",
+// "disconnection" or "server", when the match was ended by the server administration
+// or shutdown. The Seed is the random seed of the match, the inputs of its players
+// aren't recorded.
+type MatchResult struct {
+	SessionID string        `json:"session_id"`
+	Mode      string        `json:"mode"`
+	Seed      uint64        `json:"seed,string"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Scores    []PlayerScore `json:"scores"`
+	Winner    *Player       `json:"winner,omitempty"`
+	Winners   []Player      `json:"winners,omitempty"`
+	Reason    string        `json:"reason"`
+	Stats     MatchStats    `json:"stats"`
+}
+
+// MatchStats are the statistics of a match
+//
+// A rally counts the paddle hits of a ball from its serve to its goal. The TopSpeed is the
+// fastest ball of the match, in pixels per tick.
+type MatchStats struct {
+	Hits         int         `json:"hits"`
+	Rallies      int         `json:"rallies"`
+	AverageRally float64     `json:"average_rally"`
+	LongestRally int         `json:"longest_rally"`
+	TopSpeed     float64     `json:"top_speed"`
+	Sides        []SideStats `json:"sides"`
+}
+
+// SideStats are the statistics of a side of the field, its time in possession adding up
+// the time of every ball last hit by the side
+type SideStats struct {
+	Side         Side  `json:"side"`
+	Hits         int   `json:"hits"`
+	Goals        int   `json:"goals"`
+	PossessionMs int64 `json:"possession_ms"`
+}
+
+func side(s geometry.Side) Side {
+	switch s {
+	case geometry.Left:
+		return SideLeft
+	case geometry.Right:
+		return SideRight
+	case physics.Top:
+		return SideTop
+	case physics.Bottom:
+		return SideBottom
+	default:
+		return SideNone
+	}
+}
+
+func player(details game.PlayerDetails) Player {
+	return Player{
+		ID:        details.ID,
+		Name:      details.Name,
+		AccountID: details.AccountID,
+		Side:      side(details.Side),
+	}
+}
+
+func scores(players []game.PlayerDetails) []PlayerScore {
+	scores := make([]PlayerScore, 0, len(players))
+	for _, details := range players {
+		scores = append(scores, PlayerScore{
+			Player: player(details),
+			Score:  int(details.Score),
+		})
+	}
+
+	return scores
+}
+
+func matchResult(result game.MatchResult) MatchResult {
+	matchResult := MatchResult{
+		SessionID: result.SessionID,
+		Mode:      string(result.Mode),
+		Seed:      result.Seed,
+		StartTime: result.StartTime,
+		EndTime:   result.EndTime,
+		Scores:    scores(result.Players),
+		Reason:    string(result.Reason),
+		Stats:     matchStats(result.Stats),
+	}
+
+	for _, details := range result.Players {
+		if result.WinnerSide != geometry.Undefined && details.Side == result.WinnerSide {
+			matchResult.Winners = append(matchResult.Winners, player(details))
+		}
+	}
+
+	if len(matchResult.Winners) > 0 {
+		matchResult.Winner = &matchResult.Winners[0]
+	}
+
+	return matchResult
+}
+
+func matchStats(stats game.MatchStats) MatchStats {
+	matchStats := MatchStats{
+		Hits:         stats.Hits,
+		Rallies:      stats.Rallies,
+		AverageRally: stats.AverageRally,
+		LongestRally: stats.LongestRally,
+		TopSpeed:     stats.TopSpeed,
+	}
+
+	for _, sideStats := range stats.Sides {
+		matchStats.Sides = append(matchStats.Sides, SideStats{
+			Side:         side(sideStats.Side),
+			Hits:         sideStats.Hits,
+			Goals:        sideStats.Goals,
+			PossessionMs: sideStats.PossessionMs,
+		})
+	}
+
+	return matchStats
+}
+
+// dispatch calls the hook matching the event, if any
+func (h Hooks) dispatch(event events.Event) {
+	switch data := event.Data.(type) {
+	case game.PlayerEvent:
+		playerEvent := PlayerEvent{Player: player(data.Player), Time: event.Time}
+		switch {
+		case event.Type == events.PlayerJoined && h.OnPlayerJoin != nil:
+			h.OnPlayerJoin(playerEvent)
+		case event.Type == events.PlayerLeft && h.OnPlayerLeave != nil:
+			h.OnPlayerLeave(playerEvent)
+		}
+	case game.MatchEvent:
+		if event.Type != events.MatchStarted || h.OnMatchStart == nil {
+			return
+		}
+
+		matchEvent := MatchStartEvent{SessionID: event.SessionID, Time: event.Time}
+		for _, details := range data.Players {
+			matchEvent.Players = append(matchEvent.Players, player(details))
+		}
+
+		h.OnMatchStart(matchEvent)
+	case game.GoalEvent:
+		if h.OnGoal != nil {
+			goalEvent := GoalEvent{
+				SessionID: event.SessionID,
+				Ball:      data.Ball,
+				Conceder:  player(data.Conceder),
+				Scores:    scores(data.Players),
+				Time:      event.Time,
+			}
+
+			if data.Scorer != nil {
+				scorer := player(*data.Scorer)
+				goalEvent.Scorer = &scorer
+			}
+
+			h.OnGoal(goalEvent)
+		}
+	case game.MatchResult:
+		if h.OnMatchEnd != nil {
+			h.OnMatchEnd(matchResult(data))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package pongoserver
+
+import (
+	"time"
+
+	"github.com/reneepc/pongo-server/internal/game"
+)
+
+// QueuedPlayer is a player waiting in the match queue
+type QueuedPlayer struct {
+	Player
+	Level    int           `json:"level"`
+	JoinTime time.Time     `json:"join_time"`
+	Latency  time.Duration `json:"latency"`
+}
+
+// Matchmaker decides which of the players waiting in the queue play together
+//
+// Match is called whenever a player joins the queue and periodically while players
+// are waiting, with the players queued for the classic mode ordered by join time. Team
+// modes are always matched in the queue order. It returns the IDs of the two
+// players to be matched, or false when no match should be started at the moment.
+type Matchmaker interface {
+	Match(queue []QueuedPlayer) (player1ID string, player2ID string, ok bool)
+}
+
+// MatchmakerFunc adapts a function to the Matchmaker interface
+type MatchmakerFunc func(queue []QueuedPlayer) (string, string, bool)
+
+func (f MatchmakerFunc) Match(queue []QueuedPlayer) (string, string, bool) {
+	return f(queue)
+}
+
+// matcher adapts a public Matchmaker to the internal matchmaking
+type matcher struct {
+	matchmaker Matchmaker
+}
+
+func (m matcher) Match(players []*game.Network) (*game.Network, *game.Network) {
+	queue := make([]QueuedPlayer, 0, len(players))
+	byID := make(map[string]*game.Network, len(players))
+	for _, network := range players {
+		queue = append(queue, QueuedPlayer{
+			Player: Player{
+				ID:        network.ID,
+				Name:      network.PlayerName,
+				AccountID: network.AccountID,
+			},
+			Level:    network.Level,
+			JoinTime: network.JoinTime,
+			Latency:  network.Latency,
+		})
+		byID[network.ID] = network
+	}
+
+	player1ID, player2ID, ok := m.matchmaker.Match(queue)
+	if !ok {
+		return nil, nil
+	}
+
+	return byID[player1ID], byID[player2ID]
+}
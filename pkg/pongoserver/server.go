@@ -0,0 +1,171 @@
+// Package pongoserver is the public API to host a Pongo multiplayer server in other programs
+//
+// A server is created from options, and its routes can be served on their own or
+// mounted on any http.ServeMux, next to the routes of the hosting program:
+//
+//	server, err := pongoserver.New(
+//		pongoserver.WithConfig(cfg),
+//		pongoserver.WithHooks(pongoserver.Hooks{
+//			OnMatchEnd: func(result pongoserver.MatchResult) { ... },
+//		}),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer server.Shutdown()
+//
+//	mux := http.NewServeMux()
+//	server.Mount(mux, "/pong")
+package pongoserver
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/reneepc/pongo-server/internal/app"
+	"github.com/reneepc/pongo-server/internal/events"
+	"github.com/reneepc/pongo-server/internal/game"
+)
+
+// Server is an embeddable Pongo server
+type Server struct {
+	app *app.App
+}
+
+type options struct {
+	config      Config
+	hooks       []Hooks
+	matchmaker  Matchmaker
+	banStore    BanStore
+	resultStore ResultStore
+}
+
+// Option customizes the server created by New
+type Option func(*options)
+
+// WithConfig sets the server configuration, DefaultConfig is used otherwise
+func WithConfig(cfg Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithHooks registers callbacks for the server game events
+//
+// It can be given multiple times, and every set of hooks is called in order.
+// The hooks are called from a dedicated goroutine, in the order the events happened.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks)
+	}
+}
+
+// WithMatchmaker replaces the default matchmaker, which matches players in the order they joined the queue
+func WithMatchmaker(matchmaker Matchmaker) Option {
+	return func(o *options) {
+		o.matchmaker = matchmaker
+	}
+}
+
+// WithBanStore replaces the default ban list persistence, the configured ban list file
+func WithBanStore(store BanStore) Option {
+	return func(o *options) {
+		o.banStore = store
+	}
+}
+
+// WithResultStore sets where the results of finished matches are stored
+func WithResultStore(store ResultStore) Option {
+	return func(o *options) {
+		o.resultStore = store
+	}
+}
+
+// New creates a server from the given options
+//
+// The configuration is validated, and the ban list is loaded from its store.
+func New(opts ...Option) (*Server, error) {
+	o := options{config: DefaultConfig()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := o.config.Validate(); err != nil {
+		return nil, err
+	}
+
+	appOptions := app.Options{
+		BanStore: o.banStore,
+	}
+
+	if o.matchmaker != nil {
+		appOptions.Matcher = matcher{matchmaker: o.matchmaker}
+	}
+
+	a, err := app.New(o.config, appOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	o.subscribe(a.Events)
+
+	return &Server{app: a}, nil
+}
+
+// Handler returns the handler serving every route of the server
+func (s *Server) Handler() http.Handler {
+	return s.app.Handler()
+}
+
+// Mount registers the server routes on the mux under the given prefix
+//
+// With the "/pong" prefix, players connect to "/pong/multiplayer" and the sessions
+// are listed at "/pong/sessions". An empty prefix mounts the routes at the root.
+func (s *Server) Mount(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		mux.Handle("/", s.Handler())
+		return
+	}
+
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, s.Handler()))
+}
+
+// ListenAndServe serves the routes on the configured port, with TLS when configured
+//
+// It always returns a non-nil error, http.ErrServerClosed after Shutdown.
+func (s *Server) ListenAndServe() error {
+	return s.app.Start()
+}
+
+// Shutdown disconnects the players waiting in the queue, ends the running matches
+// without a winner, gracefully stops serving the routes and waits for the hooks
+// to handle the remaining events
+func (s *Server) Shutdown() error {
+	return s.app.Shutdown()
+}
+
+// subscribe delivers the server events to the public hooks and result store
+func (o options) subscribe(bus *events.Bus) {
+	if len(o.hooks) > 0 {
+		bus.Subscribe("hooks", func(event events.Event) {
+			for _, hooks := range o.hooks {
+				hooks.dispatch(event)
+			}
+		})
+	}
+
+	if o.resultStore != nil {
+		bus.Subscribe("results", func(event events.Event) {
+			result, ok := event.Data.(game.MatchResult)
+			if !ok {
+				return
+			}
+
+			if err := o.resultStore.SaveResult(matchResult(result)); err != nil {
+				slog.Error("Error storing match result", slog.Any("error", err), slog.String("session_id", result.SessionID))
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package pongoserver
+
+import "github.com/reneepc/pongo-server/internal/access"
+
+// Ban is an entry of the ban list, banning an IP, a player name or an account
+type Ban = access.Ban
+
+// BanKind identifies what a ban is matched against
+type BanKind = access.BanKind
+
+const (
+	BanIP      = access.BanIP
+	BanName    = access.BanName
+	BanAccount = access.BanAccount
+)
+
+// BanStore persists the ban list, so bans survive server restarts
+//
+// Load is called once when the server is created, and Save is called with the
+// complete ban list whenever it changes.
+type BanStore = access.BanStore
+
+// FileBanStore persists the ban list as a JSON file, the default ban store
+type FileBanStore = access.FileBanStore
+
+// ResultStore stores the results of finished matches
+//
+// SaveResult is called from a dedicated goroutine once a match ends, and the
+// returned errors are logged.
+type ResultStore interface {
+	SaveResult(result MatchResult) error
+}